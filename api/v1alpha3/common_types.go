@@ -38,6 +38,35 @@ const (
 	// on the reconciled object.
 	PausedAnnotation = "cluster.x-k8s.io/paused"
 
+	// DryRunAnnotation is an annotation that can be applied to a Cluster to make controllers
+	// log the changes they would make to the objects belonging to the Cluster, without persisting them.
+	DryRunAnnotation = "cluster.x-k8s.io/dry-run"
+
+	// AttestedNodeUIDAnnotation is an annotation set by infrastructure providers on infrastructure machines
+	// to the UID of the Node they attested as running on the machine, e.g. as reported by an agent on the machine
+	// authenticated with a TPM quote. Unlike its name and providerID, a Node's UID is assigned by the API server
+	// and can't be chosen by a rogue kubelet registering in place of the machine's.
+	// It's only required when the Machine controller is run with `--verify-node-attestation`.
+	AttestedNodeUIDAnnotation = "cluster.x-k8s.io/attested-node-uid"
+
+	// TemplateRevisionOwnerLabelName is the label set on the template revisions created when rotating a template.
+	// Its value is the UID of the object the revisions are created for, e.g. a MachineDeployment.
+	TemplateRevisionOwnerLabelName = "cluster.x-k8s.io/template-revision-owner"
+
+	// InfrastructureTemplateSourceAnnotation is an annotation that can be applied to a MachineDeployment or a
+	// KubeadmControlPlane to the name of an infrastructure template of the same kind as the one in use.
+	// Controllers keep the template in use if its Spec.Template matches the source's, and otherwise reference
+	// a new revision of it with the source's Spec.Template; the source can be edited or replaced to roll out changes.
+	InfrastructureTemplateSourceAnnotation = "cluster.x-k8s.io/infrastructure-template-source"
+
+	// BootstrapTemplateSourceAnnotation is the same as InfrastructureTemplateSourceAnnotation for the bootstrap
+	// config template of a MachineDeployment.
+	BootstrapTemplateSourceAnnotation = "cluster.x-k8s.io/bootstrap-template-source"
+
+	// TemplateSourceAnnotation is the annotation set on template revisions to the name of the template
+	// of the same kind they were copied from.
+	TemplateSourceAnnotation = "cluster.x-k8s.io/template-source"
+
 	// ClusterSecretType defines the type of secret created by core components
 	ClusterSecretType corev1.SecretType = "cluster.x-k8s.io/secret" //nolint:gosec
 )
@@ -66,13 +66,17 @@ type ClusterReconciler struct {
 	Client client.Client
 	Log    logr.Logger
 
+	// SyncPeriod is the interval at which Clusters are reconciled in the absence of changes.
+	// If unset, Clusters are resynced at the manager's sync period.
+	SyncPeriod time.Duration
+
 	scheme          *runtime.Scheme
 	recorder        record.EventRecorder
 	externalTracker external.ObjectTracker
 }
 
 func (r *ClusterReconciler) SetupWithManager(mgr ctrl.Manager, options controller.Options) error {
-	controller, err := ctrl.NewControllerManagedBy(mgr).
+	controller, err := withSyncPeriod(ctrl.NewControllerManagedBy(mgr), r.SyncPeriod).
 		For(&clusterv1.Cluster{}).
 		Watches(
 			&source.Kind{Type: &clusterv1.Machine{}},
@@ -140,7 +144,8 @@ func (r *ClusterReconciler) Reconcile(req ctrl.Request) (_ ctrl.Result, reterr e
 	}
 
 	// Handle normal reconciliation loop.
-	return r.reconcile(ctx, cluster)
+	result, err := r.reconcile(ctx, cluster)
+	return requeueAfterSyncPeriod(result, r.SyncPeriod), err
 }
 
 // reconcile handles cluster reconciliation.
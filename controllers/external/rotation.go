@@ -0,0 +1,230 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package external
+
+import (
+	"context"
+	"strings"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apiserver/pkg/storage/names"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+)
+
+// RotateTemplateInput is everything needed to rotate a template.
+type RotateTemplateInput struct {
+	// Client is the controller runtime client.
+	// +required
+	Client client.Client
+
+	// TemplateRef is a reference to the template currently in use.
+	// +required
+	TemplateRef *corev1.ObjectReference
+
+	// Namespace is the Kubernetes namespace of the template.
+	// +required
+	Namespace string
+
+	// ClusterName is the cluster this object is linked to.
+	// +required
+	ClusterName string
+
+	// Template is the desired Spec.Template of the template, e.g. as read from an unstructured.
+	// +required
+	Template map[string]interface{}
+
+	// Owner is the object the template revisions are created for, e.g. a MachineDeployment.
+	// +required
+	Owner *metav1.OwnerReference
+
+	// Source is the name of the template the desired Spec.Template is copied from, if any,
+	// recorded on the revisions with clusterv1.TemplateSourceAnnotation.
+	// +optional
+	Source string
+}
+
+// RotateTemplate returns a reference to a template with the desired Spec.Template.
+// Templates are usually immutable: unless the template in use already matches, in which case it's kept as is
+// whether it's a revision or not, a new revision of it is created with a generated name, owned by in.Owner and
+// labeled with clusterv1.TemplateRevisionOwnerLabelName.
+// Callers are expected to update their reference to the returned one, and to garbage collect the revisions
+// which aren't used anymore using DeleteUnusedTemplateRevisions.
+func RotateTemplate(ctx context.Context, in *RotateTemplateInput) (*corev1.ObjectReference, error) {
+	from, err := Get(ctx, in.Client, in.TemplateRef, in.Namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	current, _, err := unstructured.NestedMap(from.Object, "spec", "template")
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to retrieve Spec.Template map on %v %q", from.GroupVersionKind(), from.GetName())
+	}
+	if apiequality.Semantic.DeepEqual(current, in.Template) {
+		return in.TemplateRef, nil
+	}
+
+	to := from.DeepCopy()
+	to.SetResourceVersion("")
+	to.SetFinalizers(nil)
+	to.SetUID("")
+	to.SetSelfLink("")
+	to.SetCreationTimestamp(metav1.Time{})
+	to.SetGeneration(0)
+	unstructured.RemoveNestedField(to.Object, "status")
+	to.SetName(names.SimpleNameGenerator.GenerateName(templateRevisionBaseName(from) + "-"))
+	to.SetNamespace(in.Namespace)
+
+	// Set the desired template.
+	if err := unstructured.SetNestedMap(to.Object, in.Template, "spec", "template"); err != nil {
+		return nil, errors.Wrapf(err, "failed to set Spec.Template map on %v %q", to.GroupVersionKind(), to.GetName())
+	}
+
+	// Set labels.
+	labels := to.GetLabels()
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	labels[clusterv1.ClusterLabelName] = in.ClusterName
+	labels[clusterv1.TemplateRevisionOwnerLabelName] = string(in.Owner.UID)
+	to.SetLabels(labels)
+
+	// Record the template the revision is copied from.
+	annotations := to.GetAnnotations()
+	if in.Source != "" {
+		if annotations == nil {
+			annotations = map[string]string{}
+		}
+		annotations[clusterv1.TemplateSourceAnnotation] = in.Source
+	} else {
+		delete(annotations, clusterv1.TemplateSourceAnnotation)
+	}
+	to.SetAnnotations(annotations)
+
+	// Set the owner reference, keeping the other owners of the template, e.g. the Cluster.
+	ownerRefs := []metav1.OwnerReference{}
+	for _, ref := range to.GetOwnerReferences() {
+		if ref.UID != in.Owner.UID {
+			ownerRefs = append(ownerRefs, ref)
+		}
+	}
+	to.SetOwnerReferences(append(ownerRefs, *in.Owner))
+
+	if err := in.Client.Create(ctx, to); err != nil {
+		return nil, errors.Wrapf(err, "failed to create revision of %v %q", from.GroupVersionKind(), from.GetName())
+	}
+
+	return GetObjectReference(to), nil
+}
+
+// GetTemplateSource returns the template with the given name and the same kind as templateRef, along with
+// its Spec.Template to be passed to RotateTemplate as the desired template. Unlike the template in use,
+// the source can be edited or replaced by its users to roll out changes.
+func GetTemplateSource(ctx context.Context, c client.Client, templateRef *corev1.ObjectReference, namespace, name string) (*unstructured.Unstructured, map[string]interface{}, error) {
+	sourceRef := templateRef.DeepCopy()
+	sourceRef.Name = name
+	source, err := Get(ctx, c, sourceRef, namespace)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	template, _, err := unstructured.NestedMap(source.Object, "spec", "template")
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "failed to retrieve Spec.Template map on %v %q", source.GroupVersionKind(), source.GetName())
+	}
+	return source, template, nil
+}
+
+// templateRevisionBaseName returns the name revisions of template are named after,
+// so that the names don't grow with each rotation.
+func templateRevisionBaseName(template *unstructured.Unstructured) string {
+	name := template.GetName()
+	if _, ok := template.GetLabels()[clusterv1.TemplateRevisionOwnerLabelName]; !ok {
+		return name
+	}
+	if i := strings.LastIndex(name, "-"); i > 0 {
+		return name[:i]
+	}
+	return name
+}
+
+// DeleteUnusedTemplateRevisionsInput is everything needed to garbage collect template revisions.
+type DeleteUnusedTemplateRevisionsInput struct {
+	// Client is the controller runtime client.
+	// +required
+	Client client.Client
+
+	// TemplateRef is a reference to the template currently in use, revisions of the same kind are garbage collected.
+	// +required
+	TemplateRef *corev1.ObjectReference
+
+	// Namespace is the Kubernetes namespace of the templates.
+	// +required
+	Namespace string
+
+	// Owner is the object the template revisions were created for.
+	// +required
+	Owner *metav1.OwnerReference
+
+	// InUse is the list of templates still referenced, e.g. by the MachineSets of a MachineDeployment.
+	// TemplateRef is always considered in use.
+	// +optional
+	InUse []*corev1.ObjectReference
+}
+
+// DeleteUnusedTemplateRevisions deletes the revisions created by RotateTemplate for in.Owner
+// that are not referenced anymore.
+func DeleteUnusedTemplateRevisions(ctx context.Context, in *DeleteUnusedTemplateRevisionsInput) error {
+	if !strings.HasSuffix(in.TemplateRef.Kind, TemplateSuffix) {
+		return nil
+	}
+
+	revisions := &unstructured.UnstructuredList{}
+	revisions.SetAPIVersion(in.TemplateRef.APIVersion)
+	revisions.SetKind(in.TemplateRef.Kind + "List")
+	if err := in.Client.List(ctx, revisions, client.InNamespace(in.Namespace),
+		client.MatchingLabels{clusterv1.TemplateRevisionOwnerLabelName: string(in.Owner.UID)}); err != nil {
+		return errors.Wrapf(err, "failed to list revisions of %s in namespace %q", in.TemplateRef.Kind, in.Namespace)
+	}
+
+	inUse := map[string]bool{in.TemplateRef.Name: true}
+	for _, ref := range in.InUse {
+		if ref != nil && ref.Kind == in.TemplateRef.Kind {
+			inUse[ref.Name] = true
+		}
+	}
+
+	var errs []error
+	for i := range revisions.Items {
+		revision := &revisions.Items[i]
+		if inUse[revision.GetName()] || !revision.GetDeletionTimestamp().IsZero() {
+			continue
+		}
+		if err := in.Client.Delete(ctx, revision); err != nil && !apierrors.IsNotFound(err) {
+			errs = append(errs, errors.Wrapf(err, "failed to delete revision %q of %s in namespace %q",
+				revision.GetName(), in.TemplateRef.Kind, in.Namespace))
+		}
+	}
+	return kerrors.NewAggregate(errs)
+}
@@ -0,0 +1,193 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package external
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+)
+
+func newTemplate(name string, labels map[string]string, spec map[string]interface{}) *unstructured.Unstructured {
+	template := &unstructured.Unstructured{}
+	if spec != nil {
+		template.Object = map[string]interface{}{
+			"spec": map[string]interface{}{
+				"template": spec,
+			},
+		}
+	}
+	template.SetAPIVersion("green.io/v1")
+	template.SetKind("GreenTemplate")
+	template.SetNamespace("test")
+	template.SetName(name)
+	template.SetLabels(labels)
+	return template
+}
+
+func TestRotateTemplate(t *testing.T) {
+	g := NewWithT(t)
+
+	clusterOwner := metav1.OwnerReference{APIVersion: clusterv1.GroupVersion.String(), Kind: "Cluster", Name: "cluster", UID: "cluster-uid"}
+	owner := &metav1.OwnerReference{APIVersion: clusterv1.GroupVersion.String(), Kind: "MachineDeployment", Name: "md", UID: "md-uid"}
+
+	template := newTemplate("green", nil, map[string]interface{}{"spec": map[string]interface{}{"size": "small"}})
+	template.SetOwnerReferences([]metav1.OwnerReference{clusterOwner})
+	fakeClient := fake.NewFakeClientWithScheme(runtime.NewScheme(), template.DeepCopy())
+
+	ref := &corev1.ObjectReference{APIVersion: "green.io/v1", Kind: "GreenTemplate", Name: "green", Namespace: "test"}
+
+	// The template in use is adopted if it matches, even if it isn't a revision.
+	small := map[string]interface{}{"spec": map[string]interface{}{"size": "small"}}
+	got, err := RotateTemplate(context.Background(), &RotateTemplateInput{
+		Client:      fakeClient,
+		TemplateRef: ref,
+		Namespace:   "test",
+		ClusterName: "cluster",
+		Template:    small,
+		Owner:       owner,
+		Source:      "green",
+	})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(got).To(Equal(ref))
+
+	// A revision is created if the template in use doesn't match.
+	large := map[string]interface{}{"spec": map[string]interface{}{"size": "large"}}
+	got, err = RotateTemplate(context.Background(), &RotateTemplateInput{
+		Client:      fakeClient,
+		TemplateRef: ref,
+		Namespace:   "test",
+		ClusterName: "cluster",
+		Template:    large,
+		Owner:       owner,
+		Source:      "green-large",
+	})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(got.Name).To(HavePrefix("green-"))
+	g.Expect(got.Kind).To(Equal("GreenTemplate"))
+
+	revision, err := Get(context.Background(), fakeClient, got, "test")
+	g.Expect(err).NotTo(HaveOccurred())
+	spec, _, err := unstructured.NestedMap(revision.Object, "spec", "template")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(spec).To(Equal(large))
+	g.Expect(revision.GetLabels()).To(HaveKeyWithValue(clusterv1.ClusterLabelName, "cluster"))
+	g.Expect(revision.GetLabels()).To(HaveKeyWithValue(clusterv1.TemplateRevisionOwnerLabelName, "md-uid"))
+	g.Expect(revision.GetAnnotations()).To(HaveKeyWithValue(clusterv1.TemplateSourceAnnotation, "green-large"))
+	g.Expect(revision.GetOwnerReferences()).To(ConsistOf(clusterOwner, *owner))
+
+	// The revision is kept as long as it matches.
+	current := got
+	got, err = RotateTemplate(context.Background(), &RotateTemplateInput{
+		Client:      fakeClient,
+		TemplateRef: current,
+		Namespace:   "test",
+		ClusterName: "cluster",
+		Template:    large,
+		Owner:       owner,
+	})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(got).To(Equal(current))
+
+	// Revisions of a revision are named after the original template.
+	got, err = RotateTemplate(context.Background(), &RotateTemplateInput{
+		Client:      fakeClient,
+		TemplateRef: current,
+		Namespace:   "test",
+		ClusterName: "cluster",
+		Template:    map[string]interface{}{"spec": map[string]interface{}{"size": "medium"}},
+		Owner:       owner,
+	})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(got.Name).NotTo(Equal(current.Name))
+	g.Expect(got.Name).To(HavePrefix("green-"))
+	g.Expect(strings.Count(got.Name, "-")).To(Equal(1))
+}
+
+func TestGetTemplateSource(t *testing.T) {
+	g := NewWithT(t)
+
+	large := map[string]interface{}{"spec": map[string]interface{}{"size": "large"}}
+	fakeClient := fake.NewFakeClientWithScheme(runtime.NewScheme(),
+		newTemplate("green", nil, map[string]interface{}{"spec": map[string]interface{}{"size": "small"}}),
+		newTemplate("green-large", nil, large),
+	)
+
+	// The source is read with the kind of the template in use.
+	ref := &corev1.ObjectReference{APIVersion: "green.io/v1", Kind: "GreenTemplate", Name: "green", Namespace: "test"}
+	source, template, err := GetTemplateSource(context.Background(), fakeClient, ref, "test", "green-large")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(source.GetName()).To(Equal("green-large"))
+	g.Expect(source.GetKind()).To(Equal("GreenTemplate"))
+	g.Expect(template).To(Equal(large))
+	g.Expect(ref.Name).To(Equal("green"))
+
+	_, _, err = GetTemplateSource(context.Background(), fakeClient, ref, "test", "missing")
+	g.Expect(apierrors.IsNotFound(errors.Cause(err))).To(BeTrue())
+}
+
+func TestDeleteUnusedTemplateRevisions(t *testing.T) {
+	g := NewWithT(t)
+
+	scheme := runtime.NewScheme()
+	scheme.AddKnownTypeWithName(schema.GroupVersionKind{Group: "green.io", Version: "v1", Kind: "GreenTemplateList"}, &unstructured.UnstructuredList{})
+
+	owned := map[string]string{clusterv1.TemplateRevisionOwnerLabelName: "md-uid"}
+	fakeClient := fake.NewFakeClientWithScheme(scheme,
+		newTemplate("green", nil, nil),
+		newTemplate("green-current", owned, nil),
+		newTemplate("green-in-use", owned, nil),
+		newTemplate("green-unused", owned, nil),
+		newTemplate("green-other-owner", map[string]string{clusterv1.TemplateRevisionOwnerLabelName: "other-uid"}, nil),
+	)
+
+	ref := func(name string) *corev1.ObjectReference {
+		return &corev1.ObjectReference{APIVersion: "green.io/v1", Kind: "GreenTemplate", Name: name, Namespace: "test"}
+	}
+
+	g.Expect(DeleteUnusedTemplateRevisions(context.Background(), &DeleteUnusedTemplateRevisionsInput{
+		Client:      fakeClient,
+		TemplateRef: ref("green-current"),
+		Namespace:   "test",
+		Owner:       &metav1.OwnerReference{APIVersion: clusterv1.GroupVersion.String(), Kind: "MachineDeployment", Name: "md", UID: "md-uid"},
+		InUse:       []*corev1.ObjectReference{ref("green-in-use"), nil},
+	})).To(Succeed())
+
+	for _, name := range []string{"green", "green-current", "green-in-use", "green-other-owner"} {
+		_, err := Get(context.Background(), fakeClient, ref(name), "test")
+		g.Expect(err).NotTo(HaveOccurred(), "expected %q to be kept", name)
+	}
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion("green.io/v1")
+	obj.SetKind("GreenTemplate")
+	err := fakeClient.Get(context.Background(), client.ObjectKey{Namespace: "test", Name: "green-unused"}, obj)
+	g.Expect(apierrors.IsNotFound(err)).To(BeTrue())
+}
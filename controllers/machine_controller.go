@@ -45,6 +45,7 @@ import (
 	"sigs.k8s.io/cluster-api/util/annotations"
 	"sigs.k8s.io/cluster-api/util/patch"
 	"sigs.k8s.io/cluster-api/util/predicates"
+	"sigs.k8s.io/cluster-api/util/secret"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
@@ -73,6 +74,17 @@ type MachineReconciler struct {
 	Client client.Client
 	Log    logr.Logger
 
+	// SyncPeriod is the interval at which Machines are reconciled in the absence of changes.
+	// If unset, Machines are resynced at the manager's sync period.
+	SyncPeriod time.Duration
+
+	// NodeVerifier, if set, must accept a Node before it's associated with a Machine.
+	// Nodes it rejects are left untouched in the workload cluster.
+	NodeVerifier NodeVerifier
+
+	// DryRun skips draining and deleting the Nodes of all Machines being deleted.
+	DryRun bool
+
 	config          *rest.Config
 	scheme          *runtime.Scheme
 	recorder        record.EventRecorder
@@ -85,7 +97,7 @@ func (r *MachineReconciler) SetupWithManager(mgr ctrl.Manager, options controlle
 		return err
 	}
 
-	controller, err := ctrl.NewControllerManagedBy(mgr).
+	controller, err := withSyncPeriod(ctrl.NewControllerManagedBy(mgr), r.SyncPeriod).
 		For(&clusterv1.Machine{}).
 		WithOptions(options).
 		WithEventFilter(predicates.ResourceNotPaused(r.Log)).
@@ -100,12 +112,24 @@ func (r *MachineReconciler) SetupWithManager(mgr ctrl.Manager, options controlle
 			ToRequests: clusterToMachines,
 		},
 		// TODO: should this wait for Cluster.Status.InfrastructureReady similar to Infra Machine resources?
-		predicates.ClusterUnpaused(r.Log),
+		predicates.Any(r.Log, predicates.ClusterUnpaused(r.Log), predicates.ClusterControlPlaneEndpointChanged(r.Log)),
 	)
 	if err != nil {
 		return errors.Wrap(err, "failed to add Watch for Clusters to controller manager")
 	}
 
+	// Resync all Machines of a Cluster when the kubeconfig used to reach the workload cluster changes.
+	err = controller.Watch(
+		&source.Kind{Type: &corev1.Secret{}},
+		&handler.EnqueueRequestsFromMapFunc{
+			ToRequests: handler.ToRequestsFunc(r.kubeconfigSecretToMachines),
+		},
+		predicates.KubeconfigSecretChanged(r.Log),
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to add Watch for kubeconfig Secrets to controller manager")
+	}
+
 	r.recorder = mgr.GetEventRecorderFor("machine-controller")
 	r.config = mgr.GetConfig()
 	r.scheme = mgr.GetScheme()
@@ -130,6 +154,26 @@ func (r *MachineReconciler) clusterToActiveMachines(a handler.MapObject) []recon
 	return requests
 }
 
+// kubeconfigSecretToMachines maps a Cluster's kubeconfig Secret to reconcile requests for all the Machines of that Cluster.
+func (r *MachineReconciler) kubeconfigSecretToMachines(o handler.MapObject) []reconcile.Request {
+	clusterName, purpose, err := secret.ParseSecretName(o.Meta.GetName())
+	if err != nil || purpose != secret.Kubeconfig {
+		return nil
+	}
+
+	machines := &clusterv1.MachineList{}
+	if err := r.Client.List(context.TODO(), machines, client.InNamespace(o.Meta.GetNamespace()), client.MatchingLabels{clusterv1.ClusterLabelName: clusterName}); err != nil {
+		r.Log.Error(err, "failed to list Machines for kubeconfig Secret", "namespace", o.Meta.GetNamespace(), "secret", o.Meta.GetName())
+		return nil
+	}
+
+	requests := make([]reconcile.Request, 0, len(machines.Items))
+	for i := range machines.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: util.ObjectKey(&machines.Items[i])})
+	}
+	return requests
+}
+
 func (r *MachineReconciler) Reconcile(req ctrl.Request) (_ ctrl.Result, reterr error) {
 	ctx := context.Background()
 	logger := r.Log.WithValues("machine", req.Name, "namespace", req.Namespace)
@@ -187,7 +231,8 @@ func (r *MachineReconciler) Reconcile(req ctrl.Request) (_ ctrl.Result, reterr e
 	}
 
 	// Handle normal reconciliation loop.
-	return r.reconcile(ctx, cluster, m)
+	result, err := r.reconcile(ctx, cluster, m)
+	return requeueAfterSyncPeriod(result, r.SyncPeriod), err
 }
 
 func (r *MachineReconciler) reconcile(ctx context.Context, cluster *clusterv1.Cluster, m *clusterv1.Machine) (ctrl.Result, error) {
@@ -266,6 +311,11 @@ func (r *MachineReconciler) reconcileDelete(ctx context.Context, cluster *cluste
 		}
 	}
 
+	if isDeleteNodeAllowed && (r.DryRun || annotations.HasDryRunAnnotation(cluster)) {
+		logger.Info("Dry-run: not draining and deleting node", "node", m.Status.NodeRef.Name)
+		isDeleteNodeAllowed = false
+	}
+
 	if isDeleteNodeAllowed {
 		// Drain node before deletion.
 		if _, exists := m.ObjectMeta.Annotations[clusterv1.ExcludeNodeDrainingAnnotation]; !exists {
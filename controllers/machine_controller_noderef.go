@@ -23,6 +23,7 @@ import (
 	"github.com/pkg/errors"
 	apicorev1 "k8s.io/api/core/v1"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/controllers/external"
 	"sigs.k8s.io/cluster-api/controllers/noderefutil"
 	"sigs.k8s.io/cluster-api/controllers/remote"
 	capierrors "sigs.k8s.io/cluster-api/errors"
@@ -34,6 +35,52 @@ var (
 	ErrNodeNotFound = errors.New("cannot find node with matching ProviderID")
 )
 
+// NodeVerifier confirms the identity of a Node before it is associated with a Machine.
+//
+// Implementations can compare the Node against data exposed by the infrastructure
+// provider (e.g. a TPM attestation result or the audience of the bootstrap token used to join)
+// to make sure a rogue Node, joined with a leaked token, never becomes the Machine's NodeRef.
+//
+// A Node failing verification is only kept from becoming the Machine's NodeRef: it stays joined
+// to the workload cluster and is neither cordoned nor deleted, so that operators can investigate it.
+type NodeVerifier interface {
+	// VerifyNode returns an error if the Node can't be trusted to back the Machine.
+	VerifyNode(ctx context.Context, cluster *clusterv1.Cluster, machine *clusterv1.Machine, node *apicorev1.Node) error
+}
+
+// NodeVerifierFunc is a function that implements NodeVerifier.
+type NodeVerifierFunc func(ctx context.Context, cluster *clusterv1.Cluster, machine *clusterv1.Machine, node *apicorev1.Node) error
+
+// VerifyNode implements NodeVerifier.
+func (f NodeVerifierFunc) VerifyNode(ctx context.Context, cluster *clusterv1.Cluster, machine *clusterv1.Machine, node *apicorev1.Node) error {
+	return f(ctx, cluster, machine, node)
+}
+
+// AttestedNodeVerifier is a NodeVerifier accepting a Node only if the infrastructure provider attested it,
+// by setting the AttestedNodeUIDAnnotation on the Machine's infrastructure object to the UID of the Node.
+type AttestedNodeVerifier struct {
+	Client client.Client
+}
+
+var _ NodeVerifier = &AttestedNodeVerifier{}
+
+// VerifyNode implements NodeVerifier.
+func (v *AttestedNodeVerifier) VerifyNode(ctx context.Context, _ *clusterv1.Cluster, machine *clusterv1.Machine, node *apicorev1.Node) error {
+	infra, err := external.Get(ctx, v.Client, &machine.Spec.InfrastructureRef, machine.Namespace)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get infrastructure of Machine %q in namespace %q", machine.Name, machine.Namespace)
+	}
+
+	attested, ok := infra.GetAnnotations()[clusterv1.AttestedNodeUIDAnnotation]
+	if !ok {
+		return errors.Errorf("%s %q has no %q annotation yet", infra.GetKind(), infra.GetName(), clusterv1.AttestedNodeUIDAnnotation)
+	}
+	if attested != string(node.UID) {
+		return errors.Errorf("%s %q attested the Node with UID %q, not %q", infra.GetKind(), infra.GetName(), attested, node.UID)
+	}
+	return nil
+}
+
 func (r *MachineReconciler) reconcileNodeRef(ctx context.Context, cluster *clusterv1.Cluster, machine *clusterv1.Machine) error {
 	logger := r.Log.WithValues("machine", machine.Name, "namespace", machine.Namespace)
 	// Check that the Machine hasn't been deleted or in the process.
@@ -64,8 +111,8 @@ func (r *MachineReconciler) reconcileNodeRef(ctx context.Context, cluster *clust
 		return err
 	}
 
-	// Get the Node reference.
-	nodeRef, err := r.getNodeReference(clusterClient, providerID)
+	// Get the references of the Nodes matching the ProviderID.
+	nodeRefs, err := r.getNodeReferences(clusterClient, providerID)
 	if err != nil {
 		if err == ErrNodeNotFound {
 			return errors.Wrapf(&capierrors.RequeueAfterError{RequeueAfter: 10 * time.Second},
@@ -76,6 +123,13 @@ func (r *MachineReconciler) reconcileNodeRef(ctx context.Context, cluster *clust
 		return err
 	}
 
+	// Pick the first Node passing verification, so that a rogue Node reusing the ProviderID can't hide the Machine's.
+	nodeRef := r.selectNodeReference(ctx, clusterClient, cluster, machine, nodeRefs)
+	if nodeRef == nil {
+		return errors.Wrapf(&capierrors.RequeueAfterError{RequeueAfter: 30 * time.Second},
+			"cannot assign NodeRef to Machine %q in namespace %q, no matching Node passed verification", machine.Name, machine.Namespace)
+	}
+
 	// Set the Machine NodeRef.
 	machine.Status.NodeRef = nodeRef
 	logger.Info("Set Machine's NodeRef", "noderef", machine.Status.NodeRef.Name)
@@ -83,9 +137,44 @@ func (r *MachineReconciler) reconcileNodeRef(ctx context.Context, cluster *clust
 	return nil
 }
 
-func (r *MachineReconciler) getNodeReference(c client.Client, providerID *noderefutil.ProviderID) (*apicorev1.ObjectReference, error) {
+// selectNodeReference returns the first of nodeRefs passing verification, or nil if none does.
+// Nodes failing verification are reported through events on the Machine.
+func (r *MachineReconciler) selectNodeReference(ctx context.Context, c client.Client, cluster *clusterv1.Cluster, machine *clusterv1.Machine, nodeRefs []*apicorev1.ObjectReference) *apicorev1.ObjectReference {
+	logger := r.Log.WithValues("machine", machine.Name, "namespace", machine.Namespace, "cluster", cluster.Name)
+
+	for _, nodeRef := range nodeRefs {
+		if err := r.verifyNode(ctx, c, cluster, machine, nodeRef); err != nil {
+			logger.Error(err, "Failed to verify Node", "node", nodeRef.Name)
+			r.recorder.Eventf(machine, apicorev1.EventTypeWarning, "FailedNodeVerification", "Node %q: %v", nodeRef.Name, err)
+			continue
+		}
+		return nodeRef
+	}
+	return nil
+}
+
+// verifyNode runs the NodeVerifier, if any, against the Node referenced by nodeRef.
+func (r *MachineReconciler) verifyNode(ctx context.Context, c client.Client, cluster *clusterv1.Cluster, machine *clusterv1.Machine, nodeRef *apicorev1.ObjectReference) error {
+	if r.NodeVerifier == nil {
+		return nil
+	}
+
+	node := &apicorev1.Node{}
+	if err := c.Get(ctx, client.ObjectKey{Name: nodeRef.Name}, node); err != nil {
+		return errors.Wrapf(err, "failed to get Node %q", nodeRef.Name)
+	}
+	if node.UID != nodeRef.UID {
+		return errors.Errorf("Node %q was replaced while being verified", nodeRef.Name)
+	}
+	return r.NodeVerifier.VerifyNode(ctx, cluster, machine, node)
+}
+
+// getNodeReferences returns the references of all the Nodes with the given ProviderID.
+// More than one Node can match, e.g. when a rogue kubelet registers with the ProviderID of the Machine.
+func (r *MachineReconciler) getNodeReferences(c client.Client, providerID *noderefutil.ProviderID) ([]*apicorev1.ObjectReference, error) {
 	logger := r.Log.WithValues("providerID", providerID)
 
+	var nodeRefs []*apicorev1.ObjectReference
 	nodeList := apicorev1.NodeList{}
 	for {
 		if err := c.List(context.TODO(), &nodeList, client.Continue(nodeList.Continue)); err != nil {
@@ -100,12 +189,12 @@ func (r *MachineReconciler) getNodeReference(c client.Client, providerID *nodere
 			}
 
 			if providerID.Equals(nodeProviderID) {
-				return &apicorev1.ObjectReference{
+				nodeRefs = append(nodeRefs, &apicorev1.ObjectReference{
 					Kind:       node.Kind,
 					APIVersion: node.APIVersion,
 					Name:       node.Name,
 					UID:        node.UID,
-				}, nil
+				})
 			}
 		}
 
@@ -114,5 +203,8 @@ func (r *MachineReconciler) getNodeReference(c client.Client, providerID *nodere
 		}
 	}
 
-	return nil, ErrNodeNotFound
+	if len(nodeRefs) == 0 {
+		return nil, ErrNodeNotFound
+	}
+	return nodeRefs, nil
 }
@@ -17,12 +17,15 @@ limitations under the License.
 package controllers
 
 import (
+	"context"
 	"testing"
 
 	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/tools/record"
@@ -33,7 +36,7 @@ import (
 	"sigs.k8s.io/cluster-api/controllers/noderefutil"
 )
 
-func TestGetNodeReference(t *testing.T) {
+func TestGetNodeReferences(t *testing.T) {
 	g := NewWithT(t)
 
 	g.Expect(clusterv1.AddToScheme(scheme.Scheme)).To(Succeed())
@@ -69,6 +72,22 @@ func TestGetNodeReference(t *testing.T) {
 				ProviderID: "gce://us-central1/id-node-2",
 			},
 		},
+		&corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "gce-node-3",
+			},
+			Spec: corev1.NodeSpec{
+				ProviderID: "gce://us-central1/id-node-3",
+			},
+		},
+		&corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "rogue-gce-node-3",
+			},
+			Spec: corev1.NodeSpec{
+				ProviderID: "gce://us-central1/id-node-3",
+			},
+		},
 	}
 
 	client := fake.NewFakeClientWithScheme(scheme.Scheme, nodeList...)
@@ -76,23 +95,28 @@ func TestGetNodeReference(t *testing.T) {
 	testCases := []struct {
 		name       string
 		providerID string
-		expected   *corev1.ObjectReference
+		expected   []string
 		err        error
 	}{
 		{
 			name:       "valid provider id, valid aws node",
 			providerID: "aws:///id-node-1",
-			expected:   &corev1.ObjectReference{Name: "node-1"},
+			expected:   []string{"node-1"},
 		},
 		{
 			name:       "valid provider id, valid aws node",
 			providerID: "aws:///id-node-2",
-			expected:   &corev1.ObjectReference{Name: "node-2"},
+			expected:   []string{"node-2"},
 		},
 		{
 			name:       "valid provider id, valid gce node",
 			providerID: "gce:///id-node-2",
-			expected:   &corev1.ObjectReference{Name: "gce-node-2"},
+			expected:   []string{"gce-node-2"},
+		},
+		{
+			name:       "valid provider id, several matching gce nodes",
+			providerID: "gce:///id-node-3",
+			expected:   []string{"gce-node-3", "rogue-gce-node-3"},
 		},
 		{
 			name:       "valid provider id, no node found",
@@ -108,21 +132,267 @@ func TestGetNodeReference(t *testing.T) {
 			providerID, err := noderefutil.NewProviderID(test.providerID)
 			gt.Expect(err).NotTo(HaveOccurred(), "Expected no error parsing provider id %q, got %v", test.providerID, err)
 
-			reference, err := r.getNodeReference(client, providerID)
+			references, err := r.getNodeReferences(client, providerID)
 			if test.err == nil {
-				g.Expect(err).To(BeNil())
+				gt.Expect(err).To(BeNil())
 			} else {
 				gt.Expect(err).NotTo(BeNil())
 				gt.Expect(err).To(Equal(test.err), "Expected error %v, got %v", test.err, err)
 			}
 
-			if test.expected == nil && reference == nil {
-				return
+			names := make([]string, 0, len(references))
+			for _, reference := range references {
+				gt.Expect(reference.Namespace).To(BeEmpty())
+				names = append(names, reference.Name)
+			}
+			gt.Expect(names).To(ConsistOf(test.expected))
+		})
+
+	}
+}
+
+func TestSelectNodeReference(t *testing.T) {
+	nodes := []runtime.Object{
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "rogue-node", UID: "rogue-uid"}},
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-1", UID: "node-1-uid"}},
+	}
+	nodeRefs := []*corev1.ObjectReference{
+		{Name: "rogue-node", UID: "rogue-uid"},
+		{Name: "node-1", UID: "node-1-uid"},
+	}
+	machine := &clusterv1.Machine{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "machine-1",
+			Namespace: "default",
+		},
+	}
+	cluster := &clusterv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "cluster-1",
+			Namespace: "default",
+		},
+	}
+	acceptUID := func(uid string) NodeVerifier {
+		return NodeVerifierFunc(func(_ context.Context, _ *clusterv1.Cluster, _ *clusterv1.Machine, n *corev1.Node) error {
+			if string(n.UID) != uid {
+				return errors.New("attestation failed")
+			}
+			return nil
+		})
+	}
+
+	testCases := []struct {
+		name     string
+		verifier NodeVerifier
+		expected string
+	}{
+		{
+			name:     "no verifier",
+			expected: "rogue-node",
+		},
+		{
+			name:     "verifier accepts a node other than the first one",
+			verifier: acceptUID("node-1-uid"),
+			expected: "node-1",
+		},
+		{
+			name:     "verifier rejects all the nodes",
+			verifier: acceptUID("other-uid"),
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			r := &MachineReconciler{
+				Client:       fake.NewFakeClientWithScheme(scheme.Scheme),
+				Log:          log.Log,
+				NodeVerifier: test.verifier,
+				recorder:     record.NewFakeRecorder(32),
+			}
+			c := fake.NewFakeClientWithScheme(scheme.Scheme, nodes...)
+
+			nodeRef := r.selectNodeReference(context.Background(), c, cluster, machine, nodeRefs)
+			if test.expected == "" {
+				g.Expect(nodeRef).To(BeNil())
+			} else {
+				g.Expect(nodeRef).NotTo(BeNil())
+				g.Expect(nodeRef.Name).To(Equal(test.expected))
+			}
+		})
+	}
+}
+
+func TestVerifyNode(t *testing.T) {
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "node-1",
+		},
+		Spec: corev1.NodeSpec{
+			ProviderID: "aws://us-east-1/id-node-1",
+		},
+	}
+	nodeRef := &corev1.ObjectReference{Name: "node-1"}
+	machine := &clusterv1.Machine{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "machine-1",
+			Namespace: "default",
+		},
+	}
+	cluster := &clusterv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "cluster-1",
+			Namespace: "default",
+		},
+	}
+
+	testCases := []struct {
+		name     string
+		verifier NodeVerifier
+		nodeRef  *corev1.ObjectReference
+		err      bool
+	}{
+		{
+			name:    "no verifier",
+			nodeRef: nodeRef,
+		},
+		{
+			name: "verifier accepts the node",
+			verifier: NodeVerifierFunc(func(_ context.Context, _ *clusterv1.Cluster, _ *clusterv1.Machine, n *corev1.Node) error {
+				if n.Spec.ProviderID != "aws://us-east-1/id-node-1" {
+					return errors.New("unexpected node")
+				}
+				return nil
+			}),
+			nodeRef: nodeRef,
+		},
+		{
+			name: "verifier rejects the node",
+			verifier: NodeVerifierFunc(func(_ context.Context, _ *clusterv1.Cluster, _ *clusterv1.Machine, _ *corev1.Node) error {
+				return errors.New("attestation failed")
+			}),
+			nodeRef: nodeRef,
+			err:     true,
+		},
+		{
+			name: "node replaced since it was found",
+			verifier: NodeVerifierFunc(func(_ context.Context, _ *clusterv1.Cluster, _ *clusterv1.Machine, _ *corev1.Node) error {
+				return nil
+			}),
+			nodeRef: &corev1.ObjectReference{Name: "node-1", UID: "old-uid"},
+			err:     true,
+		},
+		{
+			name: "node not found",
+			verifier: NodeVerifierFunc(func(_ context.Context, _ *clusterv1.Cluster, _ *clusterv1.Machine, _ *corev1.Node) error {
+				return nil
+			}),
+			nodeRef: &corev1.ObjectReference{Name: "node-2"},
+			err:     true,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			r := &MachineReconciler{
+				Client:       fake.NewFakeClientWithScheme(scheme.Scheme),
+				Log:          log.Log,
+				NodeVerifier: test.verifier,
+				recorder:     record.NewFakeRecorder(32),
 			}
+			c := fake.NewFakeClientWithScheme(scheme.Scheme, node.DeepCopy())
 
-			gt.Expect(reference.Name).To(Equal(test.expected.Name), "Expected NodeRef's name to be %v, got %v", reference.Name, test.expected.Name)
-			gt.Expect(reference.Namespace).To(Equal(test.expected.Namespace), "Expected NodeRef's namespace to be %v, got %v", reference.Namespace, test.expected.Namespace)
+			err := r.verifyNode(context.Background(), c, cluster, machine, test.nodeRef)
+			if test.err {
+				g.Expect(err).To(HaveOccurred())
+			} else {
+				g.Expect(err).NotTo(HaveOccurred())
+			}
 		})
+	}
+}
 
+func TestAttestedNodeVerifier(t *testing.T) {
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "node-1",
+			UID:  "node-1-uid",
+		},
+	}
+	machine := &clusterv1.Machine{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "machine-1",
+			Namespace: "default",
+		},
+		Spec: clusterv1.MachineSpec{
+			InfrastructureRef: corev1.ObjectReference{
+				APIVersion: "infrastructure.cluster.x-k8s.io/v1alpha3",
+				Kind:       "InfrastructureMachine",
+				Name:       "infra-machine-1",
+			},
+		},
+	}
+
+	newInfraMachine := func(annotations map[string]interface{}) *unstructured.Unstructured {
+		metadata := map[string]interface{}{
+			"name":      "infra-machine-1",
+			"namespace": "default",
+		}
+		if annotations != nil {
+			metadata["annotations"] = annotations
+		}
+		return &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"kind":       "InfrastructureMachine",
+				"apiVersion": "infrastructure.cluster.x-k8s.io/v1alpha3",
+				"metadata":   metadata,
+			},
+		}
+	}
+
+	testCases := []struct {
+		name  string
+		infra *unstructured.Unstructured
+		err   bool
+	}{
+		{
+			name:  "node attested by the infrastructure provider",
+			infra: newInfraMachine(map[string]interface{}{clusterv1.AttestedNodeUIDAnnotation: "node-1-uid"}),
+		},
+		{
+			name:  "another node attested by the infrastructure provider",
+			infra: newInfraMachine(map[string]interface{}{clusterv1.AttestedNodeUIDAnnotation: "node-2-uid"}),
+			err:   true,
+		},
+		{
+			name:  "node name attested instead of its UID",
+			infra: newInfraMachine(map[string]interface{}{clusterv1.AttestedNodeUIDAnnotation: "node-1"}),
+			err:   true,
+		},
+		{
+			name:  "no node attested yet",
+			infra: newInfraMachine(nil),
+			err:   true,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			v := &AttestedNodeVerifier{
+				Client: fake.NewFakeClientWithScheme(scheme.Scheme, test.infra),
+			}
+
+			err := v.VerifyNode(context.Background(), nil, machine, node)
+			if test.err {
+				g.Expect(err).To(HaveOccurred())
+			} else {
+				g.Expect(err).NotTo(HaveOccurred())
+			}
+		})
 	}
 }
@@ -788,6 +788,72 @@ func Test_clusterToActiveMachines(t *testing.T) {
 	}
 }
 
+func TestKubeconfigSecretToMachines(t *testing.T) {
+	newMachine := func(namespace, name, clusterName string) *clusterv1.Machine {
+		return &clusterv1.Machine{
+			TypeMeta: metav1.TypeMeta{
+				Kind: "Machine",
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: namespace,
+				Labels: map[string]string{
+					clusterv1.ClusterLabelName: clusterName,
+				},
+			},
+		}
+	}
+
+	tests := []struct {
+		name   string
+		secret *corev1.Secret
+		want   []reconcile.Request
+	}{
+		{
+			name:   "kubeconfig secret maps to the machines of its cluster",
+			secret: &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "test-cluster-kubeconfig"}},
+			want: []reconcile.Request{
+				{NamespacedName: client.ObjectKey{Namespace: "default", Name: "m1"}},
+				{NamespacedName: client.ObjectKey{Namespace: "default", Name: "m2"}},
+			},
+		},
+		{
+			name:   "kubeconfig secret of a cluster without machines",
+			secret: &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "other-cluster-kubeconfig"}},
+			want:   []reconcile.Request{},
+		},
+		{
+			name:   "secret with another purpose",
+			secret: &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "test-cluster-ca"}},
+			want:   nil,
+		},
+		{
+			name:   "secret that isn't a cluster secret",
+			secret: &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "unrelated"}},
+			want:   nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			r := &MachineReconciler{
+				Client: fake.NewFakeClientWithScheme(
+					scheme.Scheme,
+					newMachine("default", "m1", "test-cluster"),
+					newMachine("default", "m2", "test-cluster"),
+					newMachine("other", "m3", "test-cluster"),
+				),
+				Log:    log.Log,
+				scheme: scheme.Scheme,
+			}
+
+			got := r.kubeconfigSecretToMachines(handler.MapObject{Meta: tt.secret, Object: tt.secret})
+			g.Expect(got).To(ConsistOf(tt.want))
+		})
+	}
+}
+
 func TestIsDeleteNodeAllowed(t *testing.T) {
 	deletionts := metav1.Now()
 
@@ -29,6 +29,7 @@ import (
 	kerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/client-go/tools/record"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/controllers/external"
 	"sigs.k8s.io/cluster-api/util"
 	"sigs.k8s.io/cluster-api/util/annotations"
 	"sigs.k8s.io/cluster-api/util/patch"
@@ -56,7 +57,11 @@ type MachineDeploymentReconciler struct {
 	Client client.Client
 	Log    logr.Logger
 
-	recorder record.EventRecorder
+	// DryRun reports the MachineSets created, scaled and deleted as changes that would be made.
+	DryRun bool
+
+	recorder        record.EventRecorder
+	externalTracker external.ObjectTracker
 }
 
 func (r *MachineDeploymentReconciler) SetupWithManager(mgr ctrl.Manager, options controller.Options) error {
@@ -92,6 +97,9 @@ func (r *MachineDeploymentReconciler) SetupWithManager(mgr ctrl.Manager, options
 	}
 
 	r.recorder = mgr.GetEventRecorderFor("machinedeployment-controller")
+	r.externalTracker = external.ObjectTracker{
+		Controller: c,
+	}
 	return nil
 }
 
@@ -187,11 +195,25 @@ func (r *MachineDeploymentReconciler) reconcile(ctx context.Context, cluster *cl
 		}
 	}
 
+	// Rotate the templates from their sources, if any. The new references are persisted when patching
+	// the MachineDeployment, which rolls out new MachineSets on the next reconciliation.
+	rotated, err := r.rotateTemplates(ctx, d)
+	if err != nil || rotated {
+		return ctrl.Result{}, err
+	}
+
 	msList, err := r.getMachineSetsForDeployment(d)
 	if err != nil {
 		return ctrl.Result{}, err
 	}
 
+	// Garbage collect the template revisions which aren't referenced anymore.
+	if hasTemplateSource(d) {
+		if err := r.reconcileTemplateRevisions(ctx, d, msList); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
 	if d.Spec.Paused {
 		return ctrl.Result{}, r.sync(d, msList)
 	}
@@ -203,6 +225,121 @@ func (r *MachineDeploymentReconciler) reconcile(ctx context.Context, cluster *cl
 	return ctrl.Result{}, errors.Errorf("unexpected deployment strategy type: %s", d.Spec.Strategy.Type)
 }
 
+// rotateTemplates updates the MachineDeployment's template references to templates matching the source templates
+// set with clusterv1.InfrastructureTemplateSourceAnnotation and clusterv1.BootstrapTemplateSourceAnnotation,
+// see external.RotateTemplate. It returns true if a reference changed.
+func (r *MachineDeploymentReconciler) rotateTemplates(ctx context.Context, d *clusterv1.MachineDeployment) (bool, error) {
+	rotated, err := r.rotateTemplate(ctx, d, &d.Spec.Template.Spec.InfrastructureRef, d.Annotations[clusterv1.InfrastructureTemplateSourceAnnotation])
+	if err != nil {
+		return false, err
+	}
+
+	if d.Spec.Template.Spec.Bootstrap.ConfigRef == nil {
+		return rotated, nil
+	}
+	bootstrapRotated, err := r.rotateTemplate(ctx, d, d.Spec.Template.Spec.Bootstrap.ConfigRef, d.Annotations[clusterv1.BootstrapTemplateSourceAnnotation])
+	if err != nil {
+		return false, err
+	}
+
+	return rotated || bootstrapRotated, nil
+}
+
+// rotateTemplate updates ref to a template matching the source template with the given name, if any.
+// It returns true if the reference changed.
+func (r *MachineDeploymentReconciler) rotateTemplate(ctx context.Context, d *clusterv1.MachineDeployment, ref *corev1.ObjectReference, sourceName string) (bool, error) {
+	if sourceName == "" {
+		return false, nil
+	}
+	logger := r.Log.WithValues("machinedeployment", d.Name, "namespace", d.Namespace)
+
+	source, template, err := external.GetTemplateSource(ctx, r.Client, ref, d.Namespace, sourceName)
+	if err != nil {
+		if apierrors.IsNotFound(errors.Cause(err)) {
+			logger.Info("Could not find source template, skipping rotation", "kind", ref.Kind, "source", sourceName)
+			return false, nil
+		}
+		return false, err
+	}
+
+	// Watch the source templates, so that changes to them are rolled out.
+	if err := r.externalTracker.Watch(logger, source, &handler.EnqueueRequestsFromMapFunc{
+		ToRequests: handler.ToRequestsFunc(r.templateSourceToMachineDeployments),
+	}); err != nil {
+		return false, err
+	}
+
+	newRef, err := external.RotateTemplate(ctx, &external.RotateTemplateInput{
+		Client:      r.Client,
+		TemplateRef: ref,
+		Namespace:   d.Namespace,
+		ClusterName: d.Spec.ClusterName,
+		Template:    template,
+		Source:      sourceName,
+		Owner: &metav1.OwnerReference{
+			APIVersion: machineDeploymentKind.GroupVersion().String(),
+			Kind:       machineDeploymentKind.Kind,
+			Name:       d.Name,
+			UID:        d.UID,
+		},
+	})
+	if err != nil {
+		return false, err
+	}
+	if newRef.Name == ref.Name {
+		return false, nil
+	}
+
+	logger.Info("Rotated template", "kind", ref.Kind, "from", ref.Name, "to", newRef.Name, "source", sourceName)
+	ref.Name = newRef.Name
+	return true, nil
+}
+
+// hasTemplateSource returns true if any of the MachineDeployment's templates is rotated from a source template.
+func hasTemplateSource(d *clusterv1.MachineDeployment) bool {
+	return d.Annotations[clusterv1.InfrastructureTemplateSourceAnnotation] != "" ||
+		d.Annotations[clusterv1.BootstrapTemplateSourceAnnotation] != ""
+}
+
+// reconcileTemplateRevisions deletes the revisions of the MachineDeployment's templates, created when rotating them,
+// which are not referenced by the MachineDeployment or any of its MachineSets anymore.
+func (r *MachineDeploymentReconciler) reconcileTemplateRevisions(ctx context.Context, d *clusterv1.MachineDeployment, msList []*clusterv1.MachineSet) error {
+	owner := &metav1.OwnerReference{
+		APIVersion: machineDeploymentKind.GroupVersion().String(),
+		Kind:       machineDeploymentKind.Kind,
+		Name:       d.Name,
+		UID:        d.UID,
+	}
+
+	infraRefs := make([]*corev1.ObjectReference, 0, len(msList))
+	bootstrapRefs := make([]*corev1.ObjectReference, 0, len(msList))
+	for _, ms := range msList {
+		infraRefs = append(infraRefs, &ms.Spec.Template.Spec.InfrastructureRef)
+		bootstrapRefs = append(bootstrapRefs, ms.Spec.Template.Spec.Bootstrap.ConfigRef)
+	}
+
+	if err := external.DeleteUnusedTemplateRevisions(ctx, &external.DeleteUnusedTemplateRevisionsInput{
+		Client:      r.Client,
+		TemplateRef: &d.Spec.Template.Spec.InfrastructureRef,
+		Namespace:   d.Namespace,
+		Owner:       owner,
+		InUse:       infraRefs,
+	}); err != nil {
+		return err
+	}
+
+	if d.Spec.Template.Spec.Bootstrap.ConfigRef == nil {
+		return nil
+	}
+	return external.DeleteUnusedTemplateRevisions(ctx, &external.DeleteUnusedTemplateRevisionsInput{
+		Client:      r.Client,
+		TemplateRef: d.Spec.Template.Spec.Bootstrap.ConfigRef,
+		Namespace:   d.Namespace,
+		Owner:       owner,
+		InUse:       bootstrapRefs,
+	})
+}
+
 // getMachineSetsForDeployment returns a list of MachineSets associated with a MachineDeployment.
 func (r *MachineDeploymentReconciler) getMachineSetsForDeployment(d *clusterv1.MachineDeployment) ([]*clusterv1.MachineSet, error) {
 	logger := r.Log.WithValues("machinedeployemnt", d.Name, "namespace", d.Namespace)
@@ -329,6 +466,44 @@ func (r *MachineDeploymentReconciler) MachineSetToDeployments(o handler.MapObjec
 	return result
 }
 
+// templateSourceToMachineDeployments is a handler.ToRequestsFunc to be used to enqeue requests for reconciliation
+// for MachineDeployments whose templates are rotated from a source template.
+func (r *MachineDeploymentReconciler) templateSourceToMachineDeployments(o handler.MapObject) []ctrl.Request {
+	result := []ctrl.Request{}
+
+	dList := &clusterv1.MachineDeploymentList{}
+	if err := r.Client.List(context.Background(), dList, client.InNamespace(o.Meta.GetNamespace())); err != nil {
+		r.Log.Error(err, "Failed to list MachineDeployments")
+		return nil
+	}
+
+	gk := o.Object.GetObjectKind().GroupVersionKind().GroupKind()
+	isSource := func(ref *corev1.ObjectReference, sourceName string) bool {
+		return ref != nil && sourceName == o.Meta.GetName() && ref.GroupVersionKind().GroupKind() == gk
+	}
+	for _, d := range dList.Items {
+		if isSource(&d.Spec.Template.Spec.InfrastructureRef, d.Annotations[clusterv1.InfrastructureTemplateSourceAnnotation]) ||
+			isSource(d.Spec.Template.Spec.Bootstrap.ConfigRef, d.Annotations[clusterv1.BootstrapTemplateSourceAnnotation]) {
+			name := client.ObjectKey{Namespace: d.Namespace, Name: d.Name}
+			result = append(result, ctrl.Request{NamespacedName: name})
+		}
+	}
+
+	return result
+}
+
 func (r *MachineDeploymentReconciler) shouldAdopt(md *clusterv1.MachineDeployment) bool {
 	return !util.HasOwner(md.OwnerReferences, clusterv1.GroupVersion.String(), []string{"Cluster"})
 }
+
+// isDryRun returns true if the changes made for the MachineDeployment aren't persisted.
+func (r *MachineDeploymentReconciler) isDryRun(d *clusterv1.MachineDeployment) (bool, error) {
+	if r.DryRun {
+		return true, nil
+	}
+	cluster, err := util.GetClusterByName(context.Background(), r.Client, d.Namespace, d.Spec.ClusterName)
+	if err != nil {
+		return false, err
+	}
+	return annotations.HasDryRunAnnotation(cluster), nil
+}
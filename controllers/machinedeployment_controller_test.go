@@ -18,11 +18,14 @@ package controllers
 
 import (
 	"testing"
+	"time"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
+	"github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/client-go/kubernetes/scheme"
@@ -42,13 +45,17 @@ import (
 var _ reconcile.Reconciler = &MachineDeploymentReconciler{}
 
 var _ = Describe("MachineDeployment Reconciler", func() {
-	namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "md-test"}}
-	testCluster := &clusterv1.Cluster{ObjectMeta: metav1.ObjectMeta{Namespace: namespace.Name, Name: "test-cluster"}}
+	var namespace *corev1.Namespace
+	var testCluster *clusterv1.Cluster
 
 	BeforeEach(func() {
+		namespace = &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{GenerateName: "md-test-"}}
+		testCluster = &clusterv1.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "test-cluster"}}
+
 		By("Creating the namespace")
 		Expect(testEnv.Create(ctx, namespace)).To(Succeed())
 		By("Creating the Cluster")
+		testCluster.Namespace = namespace.Name
 		Expect(testEnv.Create(ctx, testCluster)).To(Succeed())
 		By("Creating the Cluster Kubeconfig Secret")
 		Expect(testEnv.CreateKubeconfigSecret(testCluster)).To(Succeed())
@@ -375,6 +382,173 @@ var _ = Describe("MachineDeployment Reconciler", func() {
 		// Validate that the controller set the cluster name label in selector.
 		Expect(deployment.Status.Selector).To(ContainSubstring(testCluster.Name))
 	})
+
+	It("Should rotate the templates of a MachineDeployment", func() {
+		labels := map[string]string{
+			"rotation":                 "true",
+			clusterv1.ClusterLabelName: testCluster.Name,
+		}
+		version := "1.10.3"
+		deployment := &clusterv1.MachineDeployment{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: "md-",
+				Namespace:    namespace.Name,
+				Annotations: map[string]string{
+					clusterv1.InfrastructureTemplateSourceAnnotation: "md-rotation-template",
+				},
+			},
+			Spec: clusterv1.MachineDeploymentSpec{
+				ClusterName:          testCluster.Name,
+				MinReadySeconds:      pointer.Int32Ptr(0),
+				Replicas:             pointer.Int32Ptr(1),
+				RevisionHistoryLimit: pointer.Int32Ptr(0),
+				Selector: metav1.LabelSelector{
+					MatchLabels: labels,
+				},
+				Strategy: &clusterv1.MachineDeploymentStrategy{
+					Type: clusterv1.RollingUpdateMachineDeploymentStrategyType,
+					RollingUpdate: &clusterv1.MachineRollingUpdateDeployment{
+						MaxUnavailable: intOrStrPtr(0),
+						MaxSurge:       intOrStrPtr(1),
+					},
+				},
+				Template: clusterv1.MachineTemplateSpec{
+					ObjectMeta: clusterv1.ObjectMeta{
+						Labels: labels,
+					},
+					Spec: clusterv1.MachineSpec{
+						ClusterName: testCluster.Name,
+						Version:     &version,
+						InfrastructureRef: corev1.ObjectReference{
+							APIVersion: "infrastructure.cluster.x-k8s.io/v1alpha3",
+							Kind:       "InfrastructureMachineTemplate",
+							Name:       "md-rotation-template",
+						},
+						Bootstrap: clusterv1.Bootstrap{
+							Data: pointer.StringPtr("data"),
+						},
+					},
+				},
+			},
+		}
+
+		// Create infrastructure template resource.
+		infraResource := map[string]interface{}{
+			"kind":       "InfrastructureMachine",
+			"apiVersion": "infrastructure.cluster.x-k8s.io/v1alpha3",
+			"metadata":   map[string]interface{}{},
+			"spec": map[string]interface{}{
+				"size":       "small",
+				"providerID": "test:////id",
+			},
+		}
+		infraTmpl := &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"spec": map[string]interface{}{
+					"template": infraResource,
+				},
+			},
+		}
+		infraTmpl.SetKind("InfrastructureMachineTemplate")
+		infraTmpl.SetAPIVersion("infrastructure.cluster.x-k8s.io/v1alpha3")
+		infraTmpl.SetName("md-rotation-template")
+		infraTmpl.SetNamespace(namespace.Name)
+		By("Creating the infrastructure template")
+		Expect(testEnv.Create(ctx, infraTmpl)).To(Succeed())
+
+		By("Creating the MachineDeployment")
+		Expect(testEnv.Create(ctx, deployment)).To(Succeed())
+		defer func() {
+			By("Deleting the MachineDeployment")
+			Expect(testEnv.Delete(ctx, deployment)).To(Succeed())
+		}()
+
+		infraRefName := func() string {
+			key := client.ObjectKey{Name: deployment.Name, Namespace: deployment.Namespace}
+			if err := testEnv.Get(ctx, key, deployment); err != nil {
+				return ""
+			}
+			return deployment.Spec.Template.Spec.InfrastructureRef.Name
+		}
+
+		By("Verifying the MachineDeployment keeps the infrastructure template in use as it matches its source")
+		Eventually(func() int {
+			machineSets := &clusterv1.MachineSetList{}
+			if err := testEnv.List(ctx, machineSets, client.InNamespace(namespace.Name), client.MatchingLabels(labels)); err != nil {
+				return -1
+			}
+			return len(machineSets.Items)
+		}, timeout).Should(BeEquivalentTo(1))
+		Consistently(infraRefName, time.Second*2).Should(Equal("md-rotation-template"))
+
+		By("Creating a source template with a different spec")
+		source := infraTmpl.DeepCopy()
+		source.SetResourceVersion("")
+		source.SetName("md-rotation-source")
+		Expect(unstructured.SetNestedField(source.Object, "medium", "spec", "template", "spec", "size")).To(Succeed())
+		Expect(testEnv.Create(ctx, source)).To(Succeed())
+
+		By("Setting the source template of the MachineDeployment")
+		modifyFunc := func(d *clusterv1.MachineDeployment) {
+			d.Annotations[clusterv1.InfrastructureTemplateSourceAnnotation] = source.GetName()
+		}
+		Expect(updateMachineDeployment(testEnv, deployment, modifyFunc)).To(Succeed())
+
+		By("Verifying the MachineDeployment references a revision of the infrastructure template")
+		Eventually(infraRefName, timeout).Should(HavePrefix("md-rotation-template-"))
+		firstRevision := deployment.Spec.Template.Spec.InfrastructureRef.DeepCopy()
+		revision, err := external.Get(ctx, testEnv, firstRevision, namespace.Name)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(revision.GetLabels()).To(HaveKeyWithValue(clusterv1.TemplateRevisionOwnerLabelName, string(deployment.UID)))
+		Expect(revision.GetAnnotations()).To(HaveKeyWithValue(clusterv1.TemplateSourceAnnotation, source.GetName()))
+		size, _, err := unstructured.NestedString(revision.Object, "spec", "template", "spec", "size")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(size).To(Equal("medium"))
+
+		By("Editing the source template without touching the MachineDeployment")
+		Expect(testEnv.Get(ctx, client.ObjectKey{Name: source.GetName(), Namespace: namespace.Name}, source)).To(Succeed())
+		Expect(unstructured.SetNestedField(source.Object, "large", "spec", "template", "spec", "size")).To(Succeed())
+		Expect(testEnv.Update(ctx, source)).To(Succeed())
+
+		By("Verifying the MachineDeployment references a new revision of the infrastructure template")
+		Eventually(func() bool {
+			name := infraRefName()
+			return name != "" && name != firstRevision.Name
+		}, timeout).Should(BeTrue())
+		secondRevision := deployment.Spec.Template.Spec.InfrastructureRef.DeepCopy()
+		Expect(secondRevision.Name).To(HavePrefix("md-rotation-template-"))
+		revision, err = external.Get(ctx, testEnv, secondRevision, namespace.Name)
+		Expect(err).NotTo(HaveOccurred())
+		size, _, err = unstructured.NestedString(revision.Object, "spec", "template", "spec", "size")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(size).To(Equal("large"))
+
+		By("Verifying the first revision is deleted once the rollout completes")
+		Eventually(func() bool {
+			// Set the Machines of the new revision as ready with a NodeRef, so that the old MachineSet is scaled down.
+			foundMachines := &clusterv1.MachineList{}
+			Expect(testEnv.List(ctx, foundMachines, client.InNamespace(namespace.Name), client.MatchingLabels(labels))).To(Succeed())
+			for i := 0; i < len(foundMachines.Items); i++ {
+				m := foundMachines.Items[i]
+				if !m.DeletionTimestamp.IsZero() {
+					continue
+				}
+				fakeInfrastructureRefReady(m.Spec.InfrastructureRef, infraResource)
+				fakeMachineNodeRef(&m)
+			}
+
+			_, err := external.Get(ctx, testEnv, firstRevision, namespace.Name)
+			return apierrors.IsNotFound(errors.Cause(err))
+		}, timeout*5).Should(BeTrue())
+
+		By("Verifying the current revision and the templates which aren't revisions are kept")
+		for _, name := range []string{secondRevision.Name, infraTmpl.GetName(), source.GetName()} {
+			ref := secondRevision.DeepCopy()
+			ref.Name = name
+			_, err = external.Get(ctx, testEnv, ref, namespace.Name)
+			Expect(err).NotTo(HaveOccurred())
+		}
+	})
 })
 
 func TestMachineSetToDeployments(t *testing.T) {
@@ -489,6 +663,60 @@ func TestMachineSetToDeployments(t *testing.T) {
 	}
 }
 
+func TestTemplateSourceToMachineDeployments(t *testing.T) {
+	g := NewWithT(t)
+
+	source := &unstructured.Unstructured{}
+	source.SetAPIVersion("infrastructure.cluster.x-k8s.io/v1alpha3")
+	source.SetKind("InfrastructureMachineTemplate")
+	source.SetNamespace("test")
+	source.SetName("source")
+
+	newMachineDeployment := func(name, infraKind string, annotations map[string]string) *clusterv1.MachineDeployment {
+		return &clusterv1.MachineDeployment{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "test", Annotations: annotations},
+			Spec: clusterv1.MachineDeploymentSpec{
+				Template: clusterv1.MachineTemplateSpec{
+					Spec: clusterv1.MachineSpec{
+						InfrastructureRef: corev1.ObjectReference{
+							APIVersion: "infrastructure.cluster.x-k8s.io/v1alpha3",
+							Kind:       infraKind,
+							Name:       "infra",
+						},
+						Bootstrap: clusterv1.Bootstrap{
+							ConfigRef: &corev1.ObjectReference{
+								APIVersion: "bootstrap.cluster.x-k8s.io/v1alpha3",
+								Kind:       "BootstrapConfigTemplate",
+								Name:       "bootstrap",
+							},
+						},
+					},
+				},
+			},
+		}
+	}
+
+	g.Expect(clusterv1.AddToScheme(scheme.Scheme)).To(Succeed())
+	r := &MachineDeploymentReconciler{
+		Client: fake.NewFakeClientWithScheme(scheme.Scheme,
+			newMachineDeployment("infra-source", "InfrastructureMachineTemplate",
+				map[string]string{clusterv1.InfrastructureTemplateSourceAnnotation: "source"}),
+			newMachineDeployment("other-source", "InfrastructureMachineTemplate",
+				map[string]string{clusterv1.InfrastructureTemplateSourceAnnotation: "other"}),
+			newMachineDeployment("other-kind", "OtherMachineTemplate",
+				map[string]string{clusterv1.InfrastructureTemplateSourceAnnotation: "source"}),
+			newMachineDeployment("bootstrap-source", "InfrastructureMachineTemplate",
+				map[string]string{clusterv1.BootstrapTemplateSourceAnnotation: "source"}),
+			newMachineDeployment("no-source", "InfrastructureMachineTemplate", nil),
+		),
+		Log:      log.Log,
+		recorder: record.NewFakeRecorder(32),
+	}
+
+	got := r.templateSourceToMachineDeployments(handler.MapObject{Meta: source, Object: source})
+	g.Expect(got).To(ConsistOf(reconcile.Request{NamespacedName: client.ObjectKey{Namespace: "test", Name: "infra-source"}}))
+}
+
 func TestGetMachineDeploymentsForMachineSet(t *testing.T) {
 	g := NewWithT(t)
 
@@ -734,3 +962,35 @@ func TestGetMachineSetsForDeployment(t *testing.T) {
 		})
 	}
 }
+
+func TestMachineDeploymentIsDryRun(t *testing.T) {
+	g := NewWithT(t)
+	g.Expect(clusterv1.AddToScheme(scheme.Scheme)).To(Succeed())
+
+	dryRunCluster := &clusterv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   "default",
+			Name:        "dry-run",
+			Annotations: map[string]string{clusterv1.DryRunAnnotation: ""},
+		},
+	}
+	cluster := &clusterv1.Cluster{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "test"}}
+	newDeployment := func(clusterName string) *clusterv1.MachineDeployment {
+		return &clusterv1.MachineDeployment{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "md"},
+			Spec:       clusterv1.MachineDeploymentSpec{ClusterName: clusterName},
+		}
+	}
+
+	r := &MachineDeploymentReconciler{
+		Client: fake.NewFakeClientWithScheme(scheme.Scheme, dryRunCluster, cluster),
+		Log:    log.Log,
+	}
+	g.Expect(r.isDryRun(newDeployment("test"))).To(BeFalse())
+	g.Expect(r.isDryRun(newDeployment("dry-run"))).To(BeTrue())
+	_, err := r.isDryRun(newDeployment("missing"))
+	g.Expect(err).To(HaveOccurred())
+
+	r.DryRun = true
+	g.Expect(r.isDryRun(newDeployment("test"))).To(BeTrue())
+}
@@ -211,8 +211,17 @@ func (r *MachineDeploymentReconciler) getNewMachineSet(d *clusterv1.MachineDeplo
 	}
 
 	if !alreadyExists {
-		logger.V(4).Info("Created new machine set", "machineset", createdMS.Name)
-		r.recorder.Eventf(d, corev1.EventTypeNormal, "SuccessfulCreate", "Created MachineSet %q", newMS.Name)
+		dryRun, err := r.isDryRun(d)
+		if err != nil {
+			return nil, err
+		}
+		if dryRun {
+			logger.Info("Dry-run: would create new machine set", "machineset", createdMS.Name)
+			r.recorder.Eventf(d, corev1.EventTypeNormal, "DryRunCreate", "Would create MachineSet %q", newMS.Name)
+		} else {
+			logger.V(4).Info("Created new machine set", "machineset", createdMS.Name)
+			r.recorder.Eventf(d, corev1.EventTypeNormal, "SuccessfulCreate", "Created MachineSet %q", newMS.Name)
+		}
 	}
 
 	err = r.updateMachineDeployment(d, func(innerDeployment *clusterv1.MachineDeployment) {
@@ -435,13 +444,22 @@ func (r *MachineDeploymentReconciler) scaleMachineSetOperation(ms *clusterv1.Mac
 		*(ms.Spec.Replicas) = newScale
 		mdutil.SetReplicasAnnotations(ms, *(deployment.Spec.Replicas), *(deployment.Spec.Replicas)+mdutil.MaxSurge(*deployment))
 
-		err = patchHelper.Patch(context.Background(), ms)
-		if err != nil {
+		if err := patchHelper.Patch(context.Background(), ms); err != nil {
 			r.recorder.Eventf(deployment, corev1.EventTypeWarning, "FailedScale", "Failed to scale MachineSet %q: %v", ms.Name, err)
-		} else if sizeNeedsUpdate {
-			r.recorder.Eventf(deployment, corev1.EventTypeNormal, "SuccessfulScale", "Scaled %s MachineSet %q to %d", scaleOperation, ms.Name, newScale)
+			return err
 		}
-		return err
+		if sizeNeedsUpdate {
+			dryRun, err := r.isDryRun(deployment)
+			if err != nil {
+				return err
+			}
+			if dryRun {
+				r.recorder.Eventf(deployment, corev1.EventTypeNormal, "DryRunScale", "Would scale %s MachineSet %q to %d", scaleOperation, ms.Name, newScale)
+			} else {
+				r.recorder.Eventf(deployment, corev1.EventTypeNormal, "SuccessfulScale", "Scaled %s MachineSet %q to %d", scaleOperation, ms.Name, newScale)
+			}
+		}
+		return nil
 	}
 
 	return nil
@@ -490,6 +508,14 @@ func (r *MachineDeploymentReconciler) cleanupDeployment(oldMSs []*clusterv1.Mach
 			r.recorder.Eventf(deployment, corev1.EventTypeWarning, "FailedDelete", "Failed to delete MachineSet %q: %v", ms.Name, err)
 			return err
 		}
+		dryRun, err := r.isDryRun(deployment)
+		if err != nil {
+			return err
+		}
+		if dryRun {
+			r.recorder.Eventf(deployment, corev1.EventTypeNormal, "DryRunDelete", "Would delete MachineSet %q", ms.Name)
+			continue
+		}
 		r.recorder.Eventf(deployment, corev1.EventTypeNormal, "SuccessfulDelete", "Deleted MachineSet %q", ms.Name)
 	}
 
@@ -19,7 +19,6 @@ package controllers
 import (
 	"context"
 	"fmt"
-	"sync"
 	"time"
 
 	"github.com/go-logr/logr"
@@ -65,18 +64,22 @@ const (
 
 // MachineHealthCheckReconciler reconciles a MachineHealthCheck object
 type MachineHealthCheckReconciler struct {
-	Client client.Client
-	Log    logr.Logger
-
-	controller        controller.Controller
-	recorder          record.EventRecorder
-	scheme            *runtime.Scheme
-	clusterCaches     map[client.ObjectKey]cache.Cache
-	clusterCachesLock sync.RWMutex
+	Client  client.Client
+	Log     logr.Logger
+	Tracker *remote.ClusterCacheTracker
+
+	// SyncPeriod is the interval at which MachineHealthChecks are reconciled in the absence of changes.
+	// If unset, MachineHealthChecks are resynced at the manager's sync period.
+	SyncPeriod time.Duration
+
+	controller       controller.Controller
+	recorder         record.EventRecorder
+	scheme           *runtime.Scheme
+	nodeEventHandler handler.EventHandler
 }
 
 func (r *MachineHealthCheckReconciler) SetupWithManager(mgr ctrl.Manager, options controller.Options) error {
-	controller, err := ctrl.NewControllerManagedBy(mgr).
+	controller, err := withSyncPeriod(ctrl.NewControllerManagedBy(mgr), r.SyncPeriod).
 		For(&clusterv1.MachineHealthCheck{}).
 		Watches(
 			&source.Kind{Type: &clusterv1.Machine{}},
@@ -92,12 +95,23 @@ func (r *MachineHealthCheckReconciler) SetupWithManager(mgr ctrl.Manager, option
 		&source.Kind{Type: &clusterv1.Cluster{}},
 		&handler.EnqueueRequestsFromMapFunc{ToRequests: handler.ToRequestsFunc(r.clusterToMachineHealthCheck)},
 		// TODO: should this wait for Cluster.Status.InfrastructureReady similar to Infra Machine resources?
-		predicates.ClusterUnpaused(r.Log),
+		predicates.Any(r.Log, predicates.ClusterUnpaused(r.Log), predicates.ClusterControlPlaneEndpointChanged(r.Log)),
 	)
 	if err != nil {
 		return errors.Wrap(err, "failed to add Watch for Clusters to controller manager")
 	}
 
+	// Re-create the watch for Nodes when the cache for a workload cluster is stopped because its connection details changed.
+	if r.Tracker != nil {
+		err = r.Tracker.WatchClusterCacheStopped(
+			controller,
+			&handler.EnqueueRequestsFromMapFunc{ToRequests: handler.ToRequestsFunc(r.clusterToMachineHealthCheck)},
+		)
+		if err != nil {
+			return errors.Wrap(err, "failed to add Watch for stopped cluster caches to controller manager")
+		}
+	}
+
 	// Add index to MachineHealthCheck for listing by Cluster Name
 	if err := mgr.GetCache().IndexField(&clusterv1.MachineHealthCheck{},
 		mhcClusterNameIndex,
@@ -117,7 +131,7 @@ func (r *MachineHealthCheckReconciler) SetupWithManager(mgr ctrl.Manager, option
 	r.controller = controller
 	r.recorder = mgr.GetEventRecorderFor("machinehealthcheck-controller")
 	r.scheme = mgr.GetScheme()
-	r.clusterCaches = make(map[client.ObjectKey]cache.Cache)
+	r.nodeEventHandler = &handler.EnqueueRequestsFromMapFunc{ToRequests: handler.ToRequestsFunc(r.nodeToMachineHealthCheck)}
 	return nil
 }
 
@@ -181,7 +195,7 @@ func (r *MachineHealthCheckReconciler) Reconcile(req ctrl.Request) (_ ctrl.Resul
 		return ctrl.Result{}, err
 	}
 
-	return result, nil
+	return requeueAfterSyncPeriod(result, r.SyncPeriod), nil
 }
 
 func (r *MachineHealthCheckReconciler) reconcile(ctx context.Context, cluster *clusterv1.Cluster, m *clusterv1.MachineHealthCheck) (ctrl.Result, error) {
@@ -203,7 +217,7 @@ func (r *MachineHealthCheckReconciler) reconcile(ctx context.Context, cluster *c
 		return ctrl.Result{}, err
 	}
 
-	if err := r.watchClusterNodes(ctx, r.Client, cluster); err != nil {
+	if err := r.watchClusterNodes(ctx, cluster); err != nil {
 		logger.Error(err, "Error watching nodes on target cluster")
 		return ctrl.Result{}, err
 	}
@@ -376,55 +390,19 @@ func (r *MachineHealthCheckReconciler) getMachineFromNode(nodeName string) (*clu
 	return &machineList.Items[0], nil
 }
 
-func (r *MachineHealthCheckReconciler) watchClusterNodes(ctx context.Context, c client.Client, cluster *clusterv1.Cluster) error {
-	key := util.ObjectKey(cluster)
-	if _, ok := r.getClusterCache(key); ok {
-		// watch was already set up for this cluster
-		return nil
-	}
-
-	return r.createClusterCache(ctx, c, key)
-}
-
-func (r *MachineHealthCheckReconciler) getClusterCache(key client.ObjectKey) (cache.Cache, bool) {
-	r.clusterCachesLock.RLock()
-	defer r.clusterCachesLock.RUnlock()
-
-	c, ok := r.clusterCaches[key]
-	return c, ok
-}
-
-func (r *MachineHealthCheckReconciler) createClusterCache(ctx context.Context, c client.Client, key client.ObjectKey) error {
-	r.clusterCachesLock.Lock()
-	defer r.clusterCachesLock.Unlock()
-
-	// Double check the key still doesn't exist under write lock
-	if _, ok := r.clusterCaches[key]; ok {
-		// An informer was created while waiting for the lock
+func (r *MachineHealthCheckReconciler) watchClusterNodes(ctx context.Context, cluster *clusterv1.Cluster) error {
+	// If there is no tracker, don't watch remote nodes
+	if r.Tracker == nil {
 		return nil
 	}
 
-	config, err := remote.RESTConfig(ctx, c, key)
-	if err != nil {
-		return errors.Wrap(err, "error fetching remote cluster config")
-	}
-
-	clusterCache, err := cache.New(config, cache.Options{})
-	if err != nil {
-		return errors.Wrap(err, "error creating cache for remote cluster")
-	}
-	go clusterCache.Start(ctx.Done())
-
-	err = r.controller.Watch(
-		source.NewKindWithCache(&corev1.Node{}, clusterCache),
-		&handler.EnqueueRequestsFromMapFunc{ToRequests: handler.ToRequestsFunc(r.nodeToMachineHealthCheck)},
-	)
-	if err != nil {
-		return errors.Wrap(err, "error watching nodes on target cluster")
-	}
-
-	r.clusterCaches[key] = clusterCache
-	return nil
+	return r.Tracker.Watch(ctx, remote.WatchInput{
+		Cluster:      util.ObjectKey(cluster),
+		Watcher:      r.controller,
+		Kind:         &corev1.Node{},
+		CacheOptions: cache.Options{},
+		EventHandler: r.nodeEventHandler,
+	})
 }
 
 func (r *MachineHealthCheckReconciler) indexMachineByNodeName(object runtime.Object) []string {
@@ -72,6 +72,9 @@ type MachineSetReconciler struct {
 	Client client.Client
 	Log    logr.Logger
 
+	// DryRun skips waiting for the Machines created and deleted to show up in the cache.
+	DryRun bool
+
 	recorder record.EventRecorder
 	scheme   *runtime.Scheme
 }
@@ -233,7 +236,7 @@ func (r *MachineSetReconciler) reconcile(ctx context.Context, cluster *clusterv1
 		filteredMachines = append(filteredMachines, machine)
 	}
 
-	syncErr := r.syncReplicas(ctx, machineSet, filteredMachines)
+	syncErr := r.syncReplicas(ctx, cluster, machineSet, filteredMachines)
 
 	ms := machineSet.DeepCopy()
 	newStatus, err := r.calculateStatus(ctx, cluster, ms, filteredMachines)
@@ -283,11 +286,13 @@ func (r *MachineSetReconciler) reconcile(ctx context.Context, cluster *clusterv1
 }
 
 // syncReplicas scales Machine resources up or down.
-func (r *MachineSetReconciler) syncReplicas(ctx context.Context, ms *clusterv1.MachineSet, machines []*clusterv1.Machine) error {
+// In dry-run mode the changes aren't persisted, so there is nothing to wait for.
+func (r *MachineSetReconciler) syncReplicas(ctx context.Context, cluster *clusterv1.Cluster, ms *clusterv1.MachineSet, machines []*clusterv1.Machine) error {
 	logger := r.Log.WithValues("machineset", ms.Name, "namespace", ms.Namespace)
 	if ms.Spec.Replicas == nil {
 		return errors.Errorf("the Replicas field in Spec for machineset %v is nil, this should not be allowed", ms.Name)
 	}
+	dryRun := r.DryRun || annotations.HasDryRunAnnotation(cluster)
 
 	diff := len(machines) - int(*(ms.Spec.Replicas))
 
@@ -359,6 +364,11 @@ func (r *MachineSetReconciler) syncReplicas(ctx context.Context, ms *clusterv1.M
 				}
 				continue
 			}
+			if dryRun {
+				logger.Info(fmt.Sprintf("Dry-run: would create machine %d of %d with name %q", i+1, diff, machine.Name))
+				r.recorder.Eventf(ms, corev1.EventTypeNormal, "DryRunCreate", "Would create machine %q", machine.Name)
+				continue
+			}
 			logger.Info(fmt.Sprintf("Created machine %d of %d with name %q", i+1, diff, machine.Name))
 			r.recorder.Eventf(ms, corev1.EventTypeNormal, "SuccessfulCreate", "Created machine %q", machine.Name)
 
@@ -392,6 +402,12 @@ func (r *MachineSetReconciler) syncReplicas(ctx context.Context, ms *clusterv1.M
 					logger.Error(err, "Unable to delete Machine", "machine", targetMachine.Name)
 					r.recorder.Eventf(ms, corev1.EventTypeWarning, "FailedDelete", "Failed to delete machine %q: %v", targetMachine.Name, err)
 					errCh <- err
+					return
+				}
+				if dryRun {
+					logger.Info("Dry-run: would delete machine", "machine", targetMachine.Name)
+					r.recorder.Eventf(ms, corev1.EventTypeNormal, "DryRunDelete", "Would delete machine %q", targetMachine.Name)
+					return
 				}
 				logger.Info("Deleted machine", "machine", targetMachine.Name)
 				r.recorder.Eventf(ms, corev1.EventTypeNormal, "SuccessfulDelete", "Deleted machine %q", targetMachine.Name)
@@ -407,6 +423,9 @@ func (r *MachineSetReconciler) syncReplicas(ctx context.Context, ms *clusterv1.M
 		if len(errs) > 0 {
 			return kerrors.NewAggregate(errs)
 		}
+		if dryRun {
+			return nil
+		}
 
 		return r.waitForMachineDeletion(machinesToDelete)
 	}
@@ -40,6 +40,7 @@ import (
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
 	"sigs.k8s.io/cluster-api/controllers/external"
 	"sigs.k8s.io/cluster-api/util"
+	"sigs.k8s.io/cluster-api/util/dryrun"
 )
 
 var _ reconcile.Reconciler = &MachineSetReconciler{}
@@ -408,6 +409,84 @@ func TestMachineSetReconcile(t *testing.T) {
 	})
 }
 
+func TestMachineSetSyncReplicasDryRun(t *testing.T) {
+	testCluster := &clusterv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   "default",
+			Name:        "test-cluster",
+			Annotations: map[string]string{clusterv1.DryRunAnnotation: ""},
+		},
+	}
+
+	t.Run("doesn't wait for machines that would be created", func(t *testing.T) {
+		g := NewWithT(t)
+		g.Expect(clusterv1.AddToScheme(scheme.Scheme)).To(Succeed())
+
+		infraTmpl := &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"spec": map[string]interface{}{
+					"template": map[string]interface{}{
+						"spec": map[string]interface{}{},
+					},
+				},
+			},
+		}
+		infraTmpl.SetKind("InfrastructureMachineTemplate")
+		infraTmpl.SetAPIVersion("infrastructure.cluster.x-k8s.io/v1alpha3")
+		infraTmpl.SetName("ms-template")
+		infraTmpl.SetNamespace("default")
+
+		replicas := int32(1)
+		ms := newMachineSet("machineset1", "test-cluster")
+		ms.Spec.Replicas = &replicas
+		ms.Spec.Template.Spec.InfrastructureRef = corev1.ObjectReference{
+			APIVersion: infraTmpl.GetAPIVersion(),
+			Kind:       infraTmpl.GetKind(),
+			Name:       infraTmpl.GetName(),
+		}
+
+		c := fake.NewFakeClientWithScheme(scheme.Scheme, testCluster.DeepCopy(), ms.DeepCopy(), infraTmpl)
+		rec := record.NewFakeRecorder(32)
+		msr := &MachineSetReconciler{
+			Client:   dryrun.NewClient(c, false, log.Log),
+			Log:      log.Log,
+			recorder: rec,
+		}
+		g.Expect(msr.syncReplicas(context.Background(), testCluster, ms, nil)).To(Succeed())
+		g.Expect(rec.Events).To(Receive(ContainSubstring("DryRunCreate")))
+
+		machines := &clusterv1.MachineList{}
+		g.Expect(c.List(context.Background(), machines)).To(Succeed())
+		g.Expect(machines.Items).To(BeEmpty())
+	})
+
+	t.Run("doesn't wait for machines that would be deleted", func(t *testing.T) {
+		g := NewWithT(t)
+		g.Expect(clusterv1.AddToScheme(scheme.Scheme)).To(Succeed())
+
+		ms := newMachineSet("machineset1", "test-cluster")
+		machine := &clusterv1.Machine{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "machine1",
+				Namespace: "default",
+			},
+			Spec: clusterv1.MachineSpec{
+				ClusterName: "test-cluster",
+			},
+		}
+
+		rec := record.NewFakeRecorder(32)
+		msr := &MachineSetReconciler{
+			Client:   fake.NewFakeClientWithScheme(scheme.Scheme, testCluster.DeepCopy(), ms.DeepCopy(), machine.DeepCopy()),
+			Log:      log.Log,
+			recorder: rec,
+			DryRun:   true,
+		}
+		g.Expect(msr.syncReplicas(context.Background(), testCluster, ms, []*clusterv1.Machine{machine})).To(Succeed())
+		g.Expect(rec.Events).To(Receive(ContainSubstring("DryRunDelete")))
+	})
+}
+
 func TestMachineSetToMachines(t *testing.T) {
 	g := NewWithT(t)
 
@@ -18,23 +18,29 @@ package remote
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"sync"
 	"time"
 
 	"github.com/go-logr/logr"
 	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/serializer"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/rest"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/util/secret"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
@@ -45,6 +51,9 @@ const (
 	healthCheckPollInterval       = 10 * time.Second
 	healthCheckRequestTimeout     = 5 * time.Second
 	healthCheckUnhealthyThreshold = 3
+
+	// clusterCacheStoppedBufferSize is the number of events that can be queued for each watcher of stopped ClusterCaches.
+	clusterCacheStoppedBufferSize = 1024
 )
 
 // clusterCache embeds cache.Cache and combines it with a stop channel.
@@ -79,6 +88,9 @@ type ClusterCacheTracker struct {
 
 	watchesLock sync.RWMutex
 	watches     map[client.ObjectKey]map[watchInfo]struct{}
+
+	stoppedWatchersLock sync.RWMutex
+	stoppedWatchers     []chan event.GenericEvent
 }
 
 // NewClusterCacheTracker creates a new ClusterCacheTracker.
@@ -197,6 +209,43 @@ func (m *ClusterCacheTracker) Watch(ctx context.Context, input WatchInput) error
 	return nil
 }
 
+// WatchClusterCacheStopped sends an event for a Cluster to eventHandler each time the ClusterCache for the Cluster
+// is stopped because its connection details changed, so that the watcher can call Watch again to re-create
+// the watches it had on the remote cluster.
+// The events contain a Cluster object with only its name and namespace set.
+func (m *ClusterCacheTracker) WatchClusterCacheStopped(watcher Watcher, eventHandler handler.EventHandler) error {
+	events := make(chan event.GenericEvent, clusterCacheStoppedBufferSize)
+	if err := watcher.Watch(&source.Channel{Source: events}, eventHandler); err != nil {
+		return errors.Wrap(err, "error creating watch for stopped cluster caches")
+	}
+
+	m.stoppedWatchersLock.Lock()
+	defer m.stoppedWatchersLock.Unlock()
+
+	m.stoppedWatchers = append(m.stoppedWatchers, events)
+	return nil
+}
+
+// notifyClusterCacheStopped sends an event for cluster to all the watchers of stopped ClusterCaches.
+func (m *ClusterCacheTracker) notifyClusterCacheStopped(cluster client.ObjectKey) {
+	m.stoppedWatchersLock.RLock()
+	defer m.stoppedWatchersLock.RUnlock()
+
+	obj := &clusterv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: cluster.Namespace,
+			Name:      cluster.Name,
+		},
+	}
+	for _, events := range m.stoppedWatchers {
+		select {
+		case events <- event.GenericEvent{Meta: obj, Object: obj}:
+		default:
+			m.log.Info("Dropping event for stopped cluster cache, buffer is full", "namespace", cluster.Namespace, "cluster", cluster.Name)
+		}
+	}
+}
+
 // getOrCreateClusterCache returns the clusterCache for cluster, creating a new ClusterCache if needed.
 func (m *ClusterCacheTracker) getOrCreateClusterCache(ctx context.Context, cluster client.ObjectKey, cacheOptions cache.Options) (*clusterCache, error) {
 	cache := m.getClusterCache(cluster)
@@ -364,11 +413,21 @@ func healthCheckPath(sourceCfg *rest.Config, requestTimeout time.Duration, path
 }
 
 // ClusterCacheReconciler is responsible for stopping remote cluster caches when
-// the cluster for the remote cache is being deleted.
+// the cluster for the remote cache is being deleted, or when the control plane endpoint
+// or kubeconfig used to connect to the cluster changes.
 type ClusterCacheReconciler struct {
 	log     logr.Logger
 	client  client.Client
 	tracker *ClusterCacheTracker
+
+	connectionsLock sync.Mutex
+	connections     map[client.ObjectKey]clusterConnection
+}
+
+// clusterConnection records the parameters used to connect to a remote cluster when it was last reconciled.
+type clusterConnection struct {
+	endpoint       clusterv1.APIEndpoint
+	kubeconfigHash string
 }
 
 func NewClusterCacheReconciler(
@@ -378,14 +437,20 @@ func NewClusterCacheReconciler(
 	cct *ClusterCacheTracker,
 ) (*ClusterCacheReconciler, error) {
 	r := &ClusterCacheReconciler{
-		log:     log,
-		client:  mgr.GetClient(),
-		tracker: cct,
+		log:         log,
+		client:      mgr.GetClient(),
+		tracker:     cct,
+		connections: make(map[client.ObjectKey]clusterConnection),
 	}
 
-	// Watch Clusters so we can stop and remove caches when Clusters are deleted.
+	// Watch Clusters and their kubeconfig Secrets so we can stop and remove caches when Clusters are deleted,
+	// or when the connection details for a Cluster change.
 	_, err := ctrl.NewControllerManagedBy(mgr).
 		For(&clusterv1.Cluster{}).
+		Watches(
+			&source.Kind{Type: &corev1.Secret{}},
+			&handler.EnqueueRequestsFromMapFunc{ToRequests: handler.ToRequestsFunc(kubeconfigSecretToCluster)},
+		).
 		WithOptions(controllerOptions).
 		Build(r)
 
@@ -396,8 +461,22 @@ func NewClusterCacheReconciler(
 	return r, nil
 }
 
+// kubeconfigSecretToCluster maps a Cluster's kubeconfig Secret to a reconcile request for the Cluster.
+func kubeconfigSecretToCluster(o handler.MapObject) []reconcile.Request {
+	clusterName, purpose, err := secret.ParseSecretName(o.Meta.GetName())
+	if err != nil || purpose != secret.Kubeconfig {
+		return nil
+	}
+
+	return []reconcile.Request{
+		{
+			NamespacedName: client.ObjectKey{Namespace: o.Meta.GetNamespace(), Name: clusterName},
+		},
+	}
+}
+
 // Reconcile reconciles Clusters and removes ClusterCaches for any Cluster that cannot be retrieved from the
-// management cluster.
+// management cluster, or whose control plane endpoint or kubeconfig changed since it was last reconciled.
 func (r *ClusterCacheReconciler) Reconcile(req reconcile.Request) (reconcile.Result, error) {
 	ctx := context.Background()
 
@@ -409,7 +488,7 @@ func (r *ClusterCacheReconciler) Reconcile(req reconcile.Request) (reconcile.Res
 	err := r.client.Get(ctx, req.NamespacedName, &cluster)
 	if err == nil {
 		log.V(4).Info("Cluster still exists")
-		return reconcile.Result{}, nil
+		return r.reconcileConnection(ctx, log, &cluster)
 	} else if !kerrors.IsNotFound(err) {
 		log.Error(err, "Error retrieving cluster")
 		return reconcile.Result{}, err
@@ -417,19 +496,86 @@ func (r *ClusterCacheReconciler) Reconcile(req reconcile.Request) (reconcile.Res
 
 	log.V(4).Info("Cluster no longer exists")
 
-	c := r.tracker.getClusterCache(req.NamespacedName)
+	r.deleteConnection(req.NamespacedName)
+	r.stopClusterCache(log, req.NamespacedName)
+
+	return reconcile.Result{}, nil
+}
+
+// reconcileConnection stops the ClusterCache for cluster if the control plane endpoint or the kubeconfig
+// Secret changed since the last time the Cluster was reconciled. The next call to Watch for the Cluster
+// will then create a new ClusterCache using the current connection details.
+func (r *ClusterCacheReconciler) reconcileConnection(ctx context.Context, log logr.Logger, cluster *clusterv1.Cluster) (reconcile.Result, error) {
+	key := client.ObjectKey{Namespace: cluster.Namespace, Name: cluster.Name}
+
+	current := clusterConnection{
+		endpoint: cluster.Spec.ControlPlaneEndpoint,
+	}
+	kubeconfigSecret, err := secret.Get(ctx, r.client, key, secret.Kubeconfig)
+	switch {
+	case err == nil:
+		current.kubeconfigHash = kubeconfigHash(kubeconfigSecret)
+	case !kerrors.IsNotFound(err):
+		log.Error(err, "Error retrieving kubeconfig secret")
+		return reconcile.Result{}, err
+	}
+
+	r.connectionsLock.Lock()
+	previous, found := r.connections[key]
+	r.connections[key] = current
+	r.connectionsLock.Unlock()
+
+	if !found {
+		return reconcile.Result{}, nil
+	}
+
+	endpointChanged := previous.endpoint != current.endpoint
+	// A cache can only be created once the kubeconfig Secret exists, so the Secret being created doesn't invalidate it.
+	kubeconfigChanged := previous.kubeconfigHash != "" && previous.kubeconfigHash != current.kubeconfigHash
+	if !endpointChanged && !kubeconfigChanged {
+		return reconcile.Result{}, nil
+	}
+
+	log.V(4).Info("Cluster connection details changed",
+		"previousEndpoint", previous.endpoint, "endpoint", current.endpoint,
+		"previousKubeconfigHash", previous.kubeconfigHash, "kubeconfigHash", current.kubeconfigHash)
+	if r.stopClusterCache(log, key) {
+		// Let the watchers re-create their watches, which are gone with the ClusterCache.
+		r.tracker.notifyClusterCacheStopped(key)
+	}
+
+	return reconcile.Result{}, nil
+}
+
+// kubeconfigHash returns a hash of the kubeconfig stored in s, so that only changes to the kubeconfig itself,
+// and not to the metadata of the Secret, invalidate the ClusterCache.
+func kubeconfigHash(s *corev1.Secret) string {
+	hash := sha256.Sum256(s.Data[secret.KubeconfigDataName])
+	return hex.EncodeToString(hash[:])
+}
+
+func (r *ClusterCacheReconciler) deleteConnection(cluster client.ObjectKey) {
+	r.connectionsLock.Lock()
+	defer r.connectionsLock.Unlock()
+
+	delete(r.connections, cluster)
+}
+
+// stopClusterCache stops and removes the ClusterCache for cluster, along with its watches, if it exists.
+// It returns true if a ClusterCache was stopped.
+func (r *ClusterCacheReconciler) stopClusterCache(log logr.Logger, cluster client.ObjectKey) bool {
+	c := r.tracker.getClusterCache(cluster)
 	if c == nil {
 		log.V(4).Info("No current cluster cache exists - nothing to do")
-		return reconcile.Result{}, nil
+		return false
 	}
 
 	log.V(4).Info("Stopping cluster cache")
 	c.Stop()
 
-	r.tracker.deleteClusterCache(req.NamespacedName)
+	r.tracker.deleteClusterCache(cluster)
 
 	log.V(4).Info("Deleting watches for cluster cache")
-	r.tracker.deleteWatchesForCluster(req.NamespacedName)
-
-	return reconcile.Result{}, nil
+	r.tracker.deleteWatchesForCluster(cluster)
+	return true
 }
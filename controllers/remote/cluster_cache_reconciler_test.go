@@ -29,6 +29,7 @@ import (
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
 	"sigs.k8s.io/cluster-api/util"
 	"sigs.k8s.io/cluster-api/util/kubeconfig"
+	"sigs.k8s.io/cluster-api/util/secret"
 	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
@@ -326,5 +327,124 @@ var _ = Describe("ClusterCache Reconciler suite", func() {
 				},
 			}),
 		)
+
+		// assertCacheStopped checks that the given cluster cache is eventually stopped and removed, along with its watches.
+		assertCacheStopped := func(key client.ObjectKey, cc *clusterCache) {
+			Eventually(func() bool {
+				cc.lock.Lock()
+				defer cc.lock.Unlock()
+				return cc.stopped
+			}, timeout).Should(BeTrue())
+
+			Eventually(func() map[client.ObjectKey]*clusterCache {
+				cct.clusterCachesLock.RLock()
+				defer cct.clusterCachesLock.RUnlock()
+				return cct.clusterCaches
+			}, timeout).ShouldNot(HaveKey(key))
+
+			Eventually(func() map[client.ObjectKey]map[watchInfo]struct{} {
+				cct.watchesLock.RLock()
+				defer cct.watchesLock.RUnlock()
+				return cct.watches
+			}, timeout).ShouldNot(HaveKey(key))
+		}
+
+		It("should stop the cache when the control plane endpoint of a cluster changes", func() {
+			By("Updating the control plane endpoint of cluster-1")
+			cluster := &clusterv1.Cluster{}
+			Expect(k8sClient.Get(ctx, clusterRequest1.NamespacedName, cluster)).To(Succeed())
+			cluster.Spec.ControlPlaneEndpoint = clusterv1.APIEndpoint{Host: "10.0.0.1", Port: 6443}
+			Expect(k8sClient.Update(ctx, cluster)).To(Succeed())
+
+			By("Checking cluster-1's cache is stopped")
+			assertCacheStopped(clusterRequest1.NamespacedName, clusterCache1)
+
+			By("Checking the other clusters' caches are still running")
+			for _, cc := range []*clusterCache{clusterCache2, clusterCache3} {
+				cc := cc
+				Consistently(func() bool {
+					cc.lock.Lock()
+					defer cc.lock.Unlock()
+					return cc.stopped
+				}).Should(BeFalse())
+			}
+		})
+
+		It("should stop the cache when the kubeconfig of a cluster changes", func() {
+			By("Updating the kubeconfig in the secret of cluster-2")
+			kubeconfigSecret := &corev1.Secret{}
+			secretKey := client.ObjectKey{Namespace: testNamespace.GetName(), Name: fmt.Sprintf("%s-kubeconfig", clusterRequest2.Name)}
+			Expect(k8sClient.Get(ctx, secretKey, kubeconfigSecret)).To(Succeed())
+			kubeconfigSecret.Data[secret.KubeconfigDataName] = append(kubeconfigSecret.Data[secret.KubeconfigDataName], []byte("\n# rotated\n")...)
+			Expect(k8sClient.Update(ctx, kubeconfigSecret)).To(Succeed())
+
+			By("Checking cluster-2's cache is stopped")
+			assertCacheStopped(clusterRequest2.NamespacedName, clusterCache2)
+		})
+
+		It("should not stop the cache when only the metadata of the kubeconfig secret of a cluster changes", func() {
+			By("Updating the annotations of the kubeconfig secret of cluster-2")
+			kubeconfigSecret := &corev1.Secret{}
+			secretKey := client.ObjectKey{Namespace: testNamespace.GetName(), Name: fmt.Sprintf("%s-kubeconfig", clusterRequest2.Name)}
+			Expect(k8sClient.Get(ctx, secretKey, kubeconfigSecret)).To(Succeed())
+			if kubeconfigSecret.Annotations == nil {
+				kubeconfigSecret.Annotations = map[string]string{}
+			}
+			kubeconfigSecret.Annotations["test"] = "updated"
+			Expect(k8sClient.Update(ctx, kubeconfigSecret)).To(Succeed())
+
+			By("Checking cluster-2's cache is still running")
+			Consistently(func() bool {
+				clusterCache2.lock.Lock()
+				defer clusterCache2.lock.Unlock()
+				return clusterCache2.stopped
+			}).Should(BeFalse())
+		})
+
+		It("should re-create watches when the cache of a cluster is stopped", func() {
+			By("Setting up a controller watching Nodes in cluster-1 and stopped cluster caches")
+			kind := &corev1.Node{}
+			eventHandler := &handler.Funcs{}
+			var nodeController controller.Controller
+			nodeController, err := controller.New("node-watcher", mgr, controller.Options{
+				Reconciler: reconcile.Func(func(req reconcile.Request) (reconcile.Result, error) {
+					return reconcile.Result{}, cct.Watch(ctx, WatchInput{
+						Cluster:      req.NamespacedName,
+						Watcher:      nodeController,
+						Kind:         kind,
+						CacheOptions: cache.Options{},
+						EventHandler: eventHandler,
+					})
+				}),
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cct.WatchClusterCacheStopped(nodeController, &handler.EnqueueRequestForObject{})).To(Succeed())
+
+			By("Updating the control plane endpoint of cluster-1")
+			cluster := &clusterv1.Cluster{}
+			Expect(k8sClient.Get(ctx, clusterRequest1.NamespacedName, cluster)).To(Succeed())
+			cluster.Spec.ControlPlaneEndpoint = clusterv1.APIEndpoint{Host: "10.0.0.1", Port: 6443}
+			Expect(k8sClient.Update(ctx, cluster)).To(Succeed())
+
+			By("Checking cluster-1's cache is stopped")
+			Eventually(func() bool {
+				clusterCache1.lock.Lock()
+				defer clusterCache1.lock.Unlock()
+				return clusterCache1.stopped
+			}, timeout).Should(BeTrue())
+
+			By("Checking the watch for Nodes in cluster-1 is re-created with a new cache")
+			wi := watchInfo{watcher: nodeController, kind: kind, eventHandler: eventHandler}
+			Eventually(func() map[watchInfo]struct{} {
+				cct.watchesLock.RLock()
+				defer cct.watchesLock.RUnlock()
+				return cct.watches[clusterRequest1.NamespacedName]
+			}, timeout).Should(HaveKey(wi))
+			Eventually(func() *clusterCache {
+				cct.clusterCachesLock.RLock()
+				defer cct.clusterCachesLock.RUnlock()
+				return cct.clusterCaches[clusterRequest1.NamespacedName]
+			}, timeout).Should(SatisfyAll(Not(BeNil()), Not(BeIdenticalTo(clusterCache1))))
+		})
 	})
 })
@@ -30,6 +30,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/envtest/printer"
 
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/log"
+	"sigs.k8s.io/cluster-api/controllers/remote"
 	"sigs.k8s.io/cluster-api/test/helpers"
 	// +kubebuilder:scaffold:imports
 )
@@ -87,9 +88,14 @@ var _ = BeforeSuite(func(done Done) {
 		Log:      log.Log,
 		recorder: testEnv.GetEventRecorderFor("machinedeployment-controller"),
 	}).SetupWithManager(testEnv.Manager, controller.Options{MaxConcurrentReconciles: 1})).To(Succeed())
+	tracker, err := remote.NewClusterCacheTracker(log.Log, testEnv.Manager)
+	Expect(err).NotTo(HaveOccurred())
+	_, err = remote.NewClusterCacheReconciler(log.Log, testEnv.Manager, controller.Options{MaxConcurrentReconciles: 1}, tracker)
+	Expect(err).NotTo(HaveOccurred())
 	Expect((&MachineHealthCheckReconciler{
 		Client:   testEnv,
 		Log:      log.Log,
+		Tracker:  tracker,
 		recorder: testEnv.GetEventRecorderFor("machinehealthcheck-controller"),
 	}).SetupWithManager(testEnv.Manager, controller.Options{MaxConcurrentReconciles: 1})).To(Succeed())
 
@@ -0,0 +1,49 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"time"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+// withSyncPeriod configures the controller to ignore the periodic resync events of the manager's shared informers
+// when syncPeriod is set, so that the controller can be resynced at its own interval instead.
+func withSyncPeriod(b *builder.Builder, syncPeriod time.Duration) *builder.Builder {
+	if syncPeriod <= 0 {
+		return b
+	}
+	return b.WithEventFilter(&predicate.ResourceVersionChangedPredicate{})
+}
+
+// requeueAfterSyncPeriod returns a copy of result that requeues no later than syncPeriod.
+// If syncPeriod is not set, or result already asks for an immediate requeue, result is returned unchanged.
+func requeueAfterSyncPeriod(result ctrl.Result, syncPeriod time.Duration) ctrl.Result {
+	if syncPeriod <= 0 {
+		return result
+	}
+	if result.Requeue && result.RequeueAfter == 0 {
+		return result
+	}
+	if result.RequeueAfter == 0 || result.RequeueAfter > syncPeriod {
+		result.RequeueAfter = syncPeriod
+	}
+	return result
+}
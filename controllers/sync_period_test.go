@@ -0,0 +1,74 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+func TestRequeueAfterSyncPeriod(t *testing.T) {
+	tests := []struct {
+		desc       string
+		result     ctrl.Result
+		syncPeriod time.Duration
+		expect     ctrl.Result
+	}{
+		{
+			desc:       "no sync period leaves the result unchanged",
+			result:     ctrl.Result{},
+			syncPeriod: 0,
+			expect:     ctrl.Result{},
+		},
+		{
+			desc:       "empty result requeues after the sync period",
+			result:     ctrl.Result{},
+			syncPeriod: time.Minute,
+			expect:     ctrl.Result{RequeueAfter: time.Minute},
+		},
+		{
+			desc:       "immediate requeue is preserved",
+			result:     ctrl.Result{Requeue: true},
+			syncPeriod: time.Minute,
+			expect:     ctrl.Result{Requeue: true},
+		},
+		{
+			desc:       "earlier requeue is preserved",
+			result:     ctrl.Result{RequeueAfter: 10 * time.Second},
+			syncPeriod: time.Minute,
+			expect:     ctrl.Result{RequeueAfter: 10 * time.Second},
+		},
+		{
+			desc:       "later requeue is capped to the sync period",
+			result:     ctrl.Result{Requeue: true, RequeueAfter: time.Hour},
+			syncPeriod: time.Minute,
+			expect:     ctrl.Result{Requeue: true, RequeueAfter: time.Minute},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			g := NewWithT(t)
+
+			g.Expect(requeueAfterSyncPeriod(test.result, test.syncPeriod)).To(Equal(test.expect))
+		})
+	}
+}
@@ -31,6 +31,7 @@ import (
 	"k8s.io/client-go/tools/record"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
 	kubeadmv1 "sigs.k8s.io/cluster-api/bootstrap/kubeadm/types/v1beta1"
+	"sigs.k8s.io/cluster-api/controllers/external"
 	controlplanev1 "sigs.k8s.io/cluster-api/controlplane/kubeadm/api/v1alpha3"
 	"sigs.k8s.io/cluster-api/controlplane/kubeadm/internal"
 	"sigs.k8s.io/cluster-api/controlplane/kubeadm/internal/machinefilters"
@@ -59,11 +60,16 @@ import (
 
 // KubeadmControlPlaneReconciler reconciles a KubeadmControlPlane object
 type KubeadmControlPlaneReconciler struct {
-	Client     client.Client
-	Log        logr.Logger
-	scheme     *runtime.Scheme
-	controller controller.Controller
-	recorder   record.EventRecorder
+	Client client.Client
+	Log    logr.Logger
+
+	// DryRun logs the changes to workload clusters, e.g. removing etcd members, instead of making them.
+	DryRun bool
+
+	scheme          *runtime.Scheme
+	controller      controller.Controller
+	recorder        record.EventRecorder
+	externalTracker external.ObjectTracker
 
 	managementCluster         internal.ManagementCluster
 	managementClusterUncached internal.ManagementCluster
@@ -94,9 +100,17 @@ func (r *KubeadmControlPlaneReconciler) SetupWithManager(mgr ctrl.Manager, optio
 	r.scheme = mgr.GetScheme()
 	r.controller = c
 	r.recorder = mgr.GetEventRecorderFor("kubeadm-control-plane-controller")
+	r.externalTracker = external.ObjectTracker{
+		Controller: c,
+	}
 
 	if r.managementCluster == nil {
-		r.managementCluster = &internal.Management{Client: r.Client}
+		r.managementCluster = &internal.DryRunManagementCluster{
+			ManagementCluster: &internal.Management{Client: r.Client},
+			Client:            r.Client,
+			All:               r.DryRun,
+			Log:               r.Log.WithName("dry-run"),
+		}
 	}
 	if r.managementClusterUncached == nil {
 		r.managementClusterUncached = &internal.Management{Client: mgr.GetAPIReader()}
@@ -199,6 +213,19 @@ func (r *KubeadmControlPlaneReconciler) reconcile(ctx context.Context, cluster *
 		return ctrl.Result{}, err
 	}
 
+	// Rotate the infrastructure template from its source, if any. The new reference is persisted when patching
+	// the KubeadmControlPlane and triggers an upgrade of the Machines, then garbage collect the revisions which
+	// aren't referenced anymore.
+	if kcp.Annotations[clusterv1.InfrastructureTemplateSourceAnnotation] != "" {
+		rotated, err := r.rotateInfrastructureTemplate(ctx, cluster, kcp)
+		if err != nil || rotated {
+			return ctrl.Result{}, err
+		}
+		if err := r.reconcileTemplateRevisions(ctx, kcp); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
 	// Generate Cluster Certificates if needed
 	config := kcp.Spec.KubeadmConfigSpec.DeepCopy()
 	config.JoinConfiguration = nil
@@ -348,6 +375,26 @@ func (r *KubeadmControlPlaneReconciler) ClusterToKubeadmControlPlane(o handler.M
 	return nil
 }
 
+// templateSourceToKubeadmControlPlanes is a handler.ToRequestsFunc to be used to enqueue requests for reconciliation
+// for KubeadmControlPlanes whose infrastructure template is rotated from a source template.
+func (r *KubeadmControlPlaneReconciler) templateSourceToKubeadmControlPlanes(o handler.MapObject) []ctrl.Request {
+	kcpList := &controlplanev1.KubeadmControlPlaneList{}
+	if err := r.Client.List(context.Background(), kcpList, client.InNamespace(o.Meta.GetNamespace())); err != nil {
+		r.Log.Error(err, "Failed to list KubeadmControlPlanes")
+		return nil
+	}
+
+	gk := o.Object.GetObjectKind().GroupVersionKind().GroupKind()
+	result := []ctrl.Request{}
+	for _, kcp := range kcpList.Items {
+		if kcp.Annotations[clusterv1.InfrastructureTemplateSourceAnnotation] == o.Meta.GetName() &&
+			kcp.Spec.InfrastructureTemplate.GroupVersionKind().GroupKind() == gk {
+			result = append(result, ctrl.Request{NamespacedName: client.ObjectKey{Namespace: kcp.Namespace, Name: kcp.Name}})
+		}
+	}
+	return result
+}
+
 // reconcileHealth performs health checks for control plane components and etcd
 // It removes any etcd members that do not have a corresponding node.
 // Also, as a final step, checks if there is any machines that is being deleted.
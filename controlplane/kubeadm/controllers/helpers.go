@@ -39,6 +39,7 @@ import (
 	"sigs.k8s.io/cluster-api/util/patch"
 	"sigs.k8s.io/cluster-api/util/secret"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 )
 
 func (r *KubeadmControlPlaneReconciler) reconcileKubeconfig(ctx context.Context, clusterName client.ObjectKey, endpoint clusterv1.APIEndpoint, kcp *controlplanev1.KubeadmControlPlane) error {
@@ -98,6 +99,73 @@ func (r *KubeadmControlPlaneReconciler) reconcileExternalReference(ctx context.C
 	return patchHelper.Patch(ctx, obj)
 }
 
+// rotateInfrastructureTemplate updates the infrastructure template reference to a template matching the source
+// template set with clusterv1.InfrastructureTemplateSourceAnnotation, see external.RotateTemplate.
+// It returns true if the reference changed.
+func (r *KubeadmControlPlaneReconciler) rotateInfrastructureTemplate(ctx context.Context, cluster *clusterv1.Cluster, kcp *controlplanev1.KubeadmControlPlane) (bool, error) {
+	logger := r.Log.WithValues("namespace", kcp.Namespace, "kubeadmControlPlane", kcp.Name, "cluster", cluster.Name)
+	sourceName := kcp.Annotations[clusterv1.InfrastructureTemplateSourceAnnotation]
+
+	source, template, err := external.GetTemplateSource(ctx, r.Client, &kcp.Spec.InfrastructureTemplate, kcp.Namespace, sourceName)
+	if err != nil {
+		if apierrors.IsNotFound(errors.Cause(err)) {
+			logger.Info("Could not find source infrastructure template, skipping rotation", "source", sourceName)
+			return false, nil
+		}
+		return false, err
+	}
+
+	// Watch the source templates, so that changes to them are rolled out.
+	if err := r.externalTracker.Watch(logger, source, &handler.EnqueueRequestsFromMapFunc{
+		ToRequests: handler.ToRequestsFunc(r.templateSourceToKubeadmControlPlanes),
+	}); err != nil {
+		return false, err
+	}
+
+	ref, err := external.RotateTemplate(ctx, &external.RotateTemplateInput{
+		Client:      r.Client,
+		TemplateRef: &kcp.Spec.InfrastructureTemplate,
+		Namespace:   kcp.Namespace,
+		ClusterName: cluster.Name,
+		Template:    template,
+		Source:      sourceName,
+		Owner: &metav1.OwnerReference{
+			APIVersion: controlplanev1.GroupVersion.String(),
+			Kind:       "KubeadmControlPlane",
+			Name:       kcp.Name,
+			UID:        kcp.UID,
+		},
+	})
+	if err != nil {
+		return false, err
+	}
+	if ref.Name == kcp.Spec.InfrastructureTemplate.Name {
+		return false, nil
+	}
+
+	// Only the name of the infrastructure template can be changed.
+	logger.Info("Rotated infrastructure template", "from", kcp.Spec.InfrastructureTemplate.Name, "to", ref.Name, "source", sourceName)
+	kcp.Spec.InfrastructureTemplate.Name = ref.Name
+	return true, nil
+}
+
+// reconcileTemplateRevisions deletes the revisions of the infrastructure template, created when rotating it,
+// which are not referenced by the KubeadmControlPlane anymore. Machines don't need the template
+// they were cloned from, so only the current template is in use.
+func (r *KubeadmControlPlaneReconciler) reconcileTemplateRevisions(ctx context.Context, kcp *controlplanev1.KubeadmControlPlane) error {
+	return external.DeleteUnusedTemplateRevisions(ctx, &external.DeleteUnusedTemplateRevisionsInput{
+		Client:      r.Client,
+		TemplateRef: &kcp.Spec.InfrastructureTemplate,
+		Namespace:   kcp.Namespace,
+		Owner: &metav1.OwnerReference{
+			APIVersion: controlplanev1.GroupVersion.String(),
+			Kind:       "KubeadmControlPlane",
+			Name:       kcp.Name,
+			UID:        kcp.UID,
+		},
+	})
+}
+
 func (r *KubeadmControlPlaneReconciler) cloneConfigsAndGenerateMachine(ctx context.Context, cluster *clusterv1.Cluster, kcp *controlplanev1.KubeadmControlPlane, bootstrapSpec *bootstrapv1.KubeadmConfigSpec, failureDomain *string) error {
 	var errs []error
 
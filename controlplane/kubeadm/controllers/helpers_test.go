@@ -31,13 +31,16 @@ import (
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
 	bootstrapv1 "sigs.k8s.io/cluster-api/bootstrap/kubeadm/api/v1alpha3"
 	kubeadmv1 "sigs.k8s.io/cluster-api/bootstrap/kubeadm/types/v1beta1"
+	"sigs.k8s.io/cluster-api/controllers/external"
 	controlplanev1 "sigs.k8s.io/cluster-api/controlplane/kubeadm/api/v1alpha3"
 	"sigs.k8s.io/cluster-api/controlplane/kubeadm/internal"
 	"sigs.k8s.io/cluster-api/controlplane/kubeadm/internal/hash"
 	"sigs.k8s.io/cluster-api/util"
 	"sigs.k8s.io/cluster-api/util/kubeconfig"
 	"sigs.k8s.io/cluster-api/util/secret"
+	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 )
 
@@ -230,10 +233,18 @@ func TestCloneConfigsAndGenerateMachine(t *testing.T) {
 		},
 	}
 
+	source := genericMachineTemplate.DeepCopy()
+	source.SetName("infra-foo-large")
+	g.Expect(unstructured.SetNestedField(source.Object, "large", "spec", "template", "spec", "size")).To(Succeed())
+
 	kcp := &controlplanev1.KubeadmControlPlane{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      "kcp-foo",
 			Namespace: cluster.Namespace,
+			UID:       "kcp-uid",
+			Annotations: map[string]string{
+				clusterv1.InfrastructureTemplateSourceAnnotation: genericMachineTemplate.GetName(),
+			},
 		},
 		Spec: controlplanev1.KubeadmControlPlaneSpec{
 			InfrastructureTemplate: corev1.ObjectReference{
@@ -246,7 +257,7 @@ func TestCloneConfigsAndGenerateMachine(t *testing.T) {
 		},
 	}
 
-	fakeClient := newFakeClient(g, cluster.DeepCopy(), kcp.DeepCopy(), genericMachineTemplate.DeepCopy())
+	fakeClient := newFakeClient(g, cluster.DeepCopy(), kcp.DeepCopy(), genericMachineTemplate.DeepCopy(), source.DeepCopy())
 
 	r := &KubeadmControlPlaneReconciler{
 		Client:   fakeClient,
@@ -255,30 +266,79 @@ func TestCloneConfigsAndGenerateMachine(t *testing.T) {
 		scheme:   scheme.Scheme,
 	}
 
-	bootstrapSpec := &bootstrapv1.KubeadmConfigSpec{
-		JoinConfiguration: &kubeadmv1.JoinConfiguration{},
-	}
-	g.Expect(r.cloneConfigsAndGenerateMachine(context.Background(), cluster, kcp, bootstrapSpec, nil)).To(Succeed())
+	// The template in use is kept when it matches its source.
+	rotated, err := r.rotateInfrastructureTemplate(context.Background(), cluster, kcp)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(rotated).To(BeFalse())
+	g.Expect(kcp.Spec.InfrastructureTemplate.Name).To(Equal(genericMachineTemplate.GetName()))
 
-	machineList := &clusterv1.MachineList{}
-	g.Expect(fakeClient.List(context.Background(), machineList, client.InNamespace(cluster.Namespace))).To(Succeed())
-	g.Expect(machineList.Items).To(HaveLen(1))
+	// A missing source doesn't change the template in use.
+	kcp.Annotations[clusterv1.InfrastructureTemplateSourceAnnotation] = "missing"
+	rotated, err = r.rotateInfrastructureTemplate(context.Background(), cluster, kcp)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(rotated).To(BeFalse())
+	g.Expect(kcp.Spec.InfrastructureTemplate.Name).To(Equal(genericMachineTemplate.GetName()))
 
-	for _, m := range machineList.Items {
-		g.Expect(m.Namespace).To(Equal(cluster.Namespace))
-		g.Expect(m.Name).NotTo(BeEmpty())
-		g.Expect(m.Name).To(HavePrefix(kcp.Name))
+	// A different source switches to a revision of the template in use.
+	kcp.Annotations[clusterv1.InfrastructureTemplateSourceAnnotation] = source.GetName()
+	rotated, err = r.rotateInfrastructureTemplate(context.Background(), cluster, kcp)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(rotated).To(BeTrue())
+	g.Expect(kcp.Spec.InfrastructureTemplate.Name).To(HavePrefix(genericMachineTemplate.GetName() + "-"))
+	g.Expect(kcp.Spec.InfrastructureTemplate.UID).To(BeEmpty())
 
-		g.Expect(m.Spec.InfrastructureRef.Namespace).To(Equal(cluster.Namespace))
-		g.Expect(m.Spec.InfrastructureRef.Name).To(HavePrefix(genericMachineTemplate.GetName()))
-		g.Expect(m.Spec.InfrastructureRef.APIVersion).To(Equal(genericMachineTemplate.GetAPIVersion()))
-		g.Expect(m.Spec.InfrastructureRef.Kind).To(Equal("GenericMachine"))
+	revision, err := external.Get(context.Background(), fakeClient, &kcp.Spec.InfrastructureTemplate, kcp.Namespace)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(revision.GetLabels()).To(HaveKeyWithValue(clusterv1.TemplateRevisionOwnerLabelName, "kcp-uid"))
+	g.Expect(revision.GetAnnotations()).To(HaveKeyWithValue(clusterv1.TemplateSourceAnnotation, source.GetName()))
+	size, _, err := unstructured.NestedString(revision.Object, "spec", "template", "spec", "size")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(size).To(Equal("large"))
 
-		g.Expect(m.Spec.Bootstrap.ConfigRef.Namespace).To(Equal(cluster.Namespace))
-		g.Expect(m.Spec.Bootstrap.ConfigRef.Name).To(HavePrefix(kcp.Name))
-		g.Expect(m.Spec.Bootstrap.ConfigRef.APIVersion).To(Equal(bootstrapv1.GroupVersion.String()))
-		g.Expect(m.Spec.Bootstrap.ConfigRef.Kind).To(Equal("KubeadmConfig"))
+	// The revision is kept as long as the source doesn't change.
+	rotated, err = r.rotateInfrastructureTemplate(context.Background(), cluster, kcp)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(rotated).To(BeFalse())
+	g.Expect(kcp.Spec.InfrastructureTemplate.Name).To(Equal(revision.GetName()))
+}
+
+func TestKubeadmControlPlaneReconciler_templateSourceToKubeadmControlPlanes(t *testing.T) {
+	g := NewWithT(t)
+
+	source := &unstructured.Unstructured{}
+	source.SetAPIVersion("generic.io/v1")
+	source.SetKind("GenericMachineTemplate")
+	source.SetNamespace("test")
+	source.SetName("infra-source")
+
+	newKCP := func(name, kind, sourceName string) *controlplanev1.KubeadmControlPlane {
+		return &controlplanev1.KubeadmControlPlane{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        name,
+				Namespace:   "test",
+				Annotations: map[string]string{clusterv1.InfrastructureTemplateSourceAnnotation: sourceName},
+			},
+			Spec: controlplanev1.KubeadmControlPlaneSpec{
+				InfrastructureTemplate: corev1.ObjectReference{
+					APIVersion: "generic.io/v1",
+					Kind:       kind,
+					Name:       "infra",
+				},
+			},
+		}
 	}
+
+	r := &KubeadmControlPlaneReconciler{
+		Client: newFakeClient(g,
+			newKCP("kcp-1", "GenericMachineTemplate", "infra-source"),
+			newKCP("kcp-2", "GenericMachineTemplate", "other-source"),
+			newKCP("kcp-3", "OtherMachineTemplate", "infra-source"),
+		),
+		Log: log.Log,
+	}
+
+	got := r.templateSourceToKubeadmControlPlanes(handler.MapObject{Meta: source, Object: source})
+	g.Expect(got).To(ConsistOf(ctrl.Request{NamespacedName: client.ObjectKey{Namespace: "test", Name: "kcp-1"}}))
 }
 
 func TestKubeadmControlPlaneReconciler_generateMachine(t *testing.T) {
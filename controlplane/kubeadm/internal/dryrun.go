@@ -0,0 +1,140 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"context"
+
+	"github.com/blang/semver"
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	controlplanev1 "sigs.k8s.io/cluster-api/controlplane/kubeadm/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/util/annotations"
+)
+
+// DryRunManagementCluster wraps a ManagementCluster so that the changes to workload clusters are logged
+// instead of being made, either for all workload clusters or for the Clusters with the dry-run annotation.
+type DryRunManagementCluster struct {
+	ManagementCluster
+
+	// Client is used to read the Clusters.
+	Client ctrlclient.Reader
+
+	// All enables dry-run mode for all workload clusters.
+	All bool
+
+	// Log is used to log the changes that are not made.
+	Log logr.Logger
+}
+
+// GetWorkloadCluster implements ManagementCluster.
+func (m *DryRunManagementCluster) GetWorkloadCluster(ctx context.Context, clusterKey ctrlclient.ObjectKey) (WorkloadCluster, error) {
+	workloadCluster, err := m.ManagementCluster.GetWorkloadCluster(ctx, clusterKey)
+	if err != nil {
+		return nil, err
+	}
+
+	dryRun := m.All
+	if !dryRun {
+		cluster := &clusterv1.Cluster{}
+		if err := m.Client.Get(ctx, clusterKey, cluster); err != nil {
+			return nil, errors.Wrapf(err, "failed to get Cluster %q in namespace %q to check for dry-run mode", clusterKey.Name, clusterKey.Namespace)
+		}
+		dryRun = annotations.HasDryRunAnnotation(cluster)
+	}
+	if !dryRun {
+		return workloadCluster, nil
+	}
+
+	return &dryRunWorkload{
+		WorkloadCluster: workloadCluster,
+		log:             m.Log.WithValues("cluster", clusterKey.Name, "namespace", clusterKey.Namespace),
+	}, nil
+}
+
+// dryRunWorkload wraps a WorkloadCluster so that only the health and status checks are run.
+type dryRunWorkload struct {
+	WorkloadCluster
+	log logr.Logger
+}
+
+func (w *dryRunWorkload) skip(action string, keysAndValues ...interface{}) error {
+	w.log.WithValues("action", action).Info("Dry-run: not changing workload cluster", keysAndValues...)
+	return nil
+}
+
+func (w *dryRunWorkload) ReconcileKubeletRBACBinding(_ context.Context, version semver.Version) error {
+	return w.skip("reconcile kubelet RBAC binding", "version", version.String())
+}
+
+func (w *dryRunWorkload) ReconcileKubeletRBACRole(_ context.Context, version semver.Version) error {
+	return w.skip("reconcile kubelet RBAC role", "version", version.String())
+}
+
+func (w *dryRunWorkload) UpdateKubernetesVersionInKubeadmConfigMap(_ context.Context, version semver.Version) error {
+	return w.skip("update kubernetes version in kubeadm config map", "version", version.String())
+}
+
+func (w *dryRunWorkload) UpdateImageRepositoryInKubeadmConfigMap(_ context.Context, imageRepository string) error {
+	return w.skip("update image repository in kubeadm config map", "imageRepository", imageRepository)
+}
+
+func (w *dryRunWorkload) UpdateEtcdVersionInKubeadmConfigMap(_ context.Context, imageRepository, imageTag string) error {
+	return w.skip("update etcd version in kubeadm config map", "imageRepository", imageRepository, "imageTag", imageTag)
+}
+
+func (w *dryRunWorkload) UpdateKubeletConfigMap(_ context.Context, version semver.Version) error {
+	return w.skip("update kubelet config map", "version", version.String())
+}
+
+func (w *dryRunWorkload) UpdateKubeProxyImageInfo(_ context.Context, _ *controlplanev1.KubeadmControlPlane) error {
+	return w.skip("update kube-proxy image")
+}
+
+func (w *dryRunWorkload) UpdateCoreDNS(_ context.Context, _ *controlplanev1.KubeadmControlPlane) error {
+	return w.skip("update CoreDNS")
+}
+
+func (w *dryRunWorkload) RemoveEtcdMemberForMachine(_ context.Context, machine *clusterv1.Machine) error {
+	return w.skip("remove etcd member", "machine", machine.Name)
+}
+
+func (w *dryRunWorkload) RemoveMachineFromKubeadmConfigMap(_ context.Context, machine *clusterv1.Machine) error {
+	return w.skip("remove machine from kubeadm config map", "machine", machine.Name)
+}
+
+func (w *dryRunWorkload) RemoveNodeFromKubeadmConfigMap(_ context.Context, nodeName string) error {
+	return w.skip("remove node from kubeadm config map", "node", nodeName)
+}
+
+func (w *dryRunWorkload) ForwardEtcdLeadership(_ context.Context, machine *clusterv1.Machine, leaderCandidate *clusterv1.Machine) error {
+	if machine == nil || leaderCandidate == nil {
+		return w.skip("forward etcd leadership")
+	}
+	return w.skip("forward etcd leadership", "machine", machine.Name, "leaderCandidate", leaderCandidate.Name)
+}
+
+func (w *dryRunWorkload) AllowBootstrapTokensToGetNodes(_ context.Context) error {
+	return w.skip("allow bootstrap tokens to get nodes")
+}
+
+func (w *dryRunWorkload) ReconcileEtcdMembers(_ context.Context) error {
+	return w.skip("reconcile etcd members")
+}
@@ -0,0 +1,126 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+)
+
+var errWorkloadChanged = errors.New("workload cluster changed")
+
+type changeTrackingManagementCluster struct {
+	ManagementCluster
+}
+
+func (m *changeTrackingManagementCluster) GetWorkloadCluster(_ context.Context, _ ctrlclient.ObjectKey) (WorkloadCluster, error) {
+	return &changeTrackingWorkload{}, nil
+}
+
+type changeTrackingWorkload struct {
+	WorkloadCluster
+}
+
+func (w *changeTrackingWorkload) AllowBootstrapTokensToGetNodes(_ context.Context) error {
+	return errWorkloadChanged
+}
+
+func (w *changeTrackingWorkload) ReconcileEtcdMembers(_ context.Context) error {
+	return errWorkloadChanged
+}
+
+func TestDryRunManagementCluster_GetWorkloadCluster(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := clusterv1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	dryRunCluster := &clusterv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "dry-run",
+			Namespace:   "default",
+			Annotations: map[string]string{clusterv1.DryRunAnnotation: ""},
+		},
+	}
+	cluster := &clusterv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test",
+			Namespace: "default",
+		},
+	}
+
+	tests := []struct {
+		name    string
+		all     bool
+		cluster string
+		changed bool
+	}{
+		{
+			name:    "changes workload cluster without dry-run annotation",
+			cluster: "test",
+			changed: true,
+		},
+		{
+			name:    "doesn't change workload cluster with dry-run annotation",
+			cluster: "dry-run",
+			changed: false,
+		},
+		{
+			name:    "doesn't change any workload cluster in dry-run mode",
+			all:     true,
+			cluster: "test",
+			changed: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			m := &DryRunManagementCluster{
+				ManagementCluster: &changeTrackingManagementCluster{},
+				Client:            fake.NewFakeClientWithScheme(scheme, dryRunCluster.DeepCopy(), cluster.DeepCopy()),
+				All:               tt.all,
+				Log:               log.Log,
+			}
+			workloadCluster, err := m.GetWorkloadCluster(context.Background(), ctrlclient.ObjectKey{Namespace: "default", Name: tt.cluster})
+			g.Expect(err).NotTo(HaveOccurred())
+
+			for _, change := range []func(context.Context) error{
+				workloadCluster.AllowBootstrapTokensToGetNodes,
+				workloadCluster.ReconcileEtcdMembers,
+			} {
+				if tt.changed {
+					g.Expect(change(context.Background())).To(MatchError(errWorkloadChanged))
+				} else {
+					g.Expect(change(context.Background())).To(Succeed())
+				}
+			}
+		})
+	}
+}
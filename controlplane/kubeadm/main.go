@@ -35,6 +35,10 @@ import (
 	"sigs.k8s.io/cluster-api/cmd/version"
 	kubeadmcontrolplanev1alpha3 "sigs.k8s.io/cluster-api/controlplane/kubeadm/api/v1alpha3"
 	kubeadmcontrolplanecontrollers "sigs.k8s.io/cluster-api/controlplane/kubeadm/controllers"
+	kubeadmcontrolplanewebhooks "sigs.k8s.io/cluster-api/controlplane/kubeadm/webhooks"
+	expv1alpha3 "sigs.k8s.io/cluster-api/exp/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/feature"
+	"sigs.k8s.io/cluster-api/util/dryrun"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -54,6 +58,7 @@ func init() {
 	_ = clusterv1alpha3.AddToScheme(scheme)
 	_ = kubeadmcontrolplanev1alpha3.AddToScheme(scheme)
 	_ = kubeadmbootstrapv1alpha3.AddToScheme(scheme)
+	_ = expv1alpha3.AddToScheme(scheme)
 	// +kubebuilder:scaffold:scheme
 }
 
@@ -66,6 +71,7 @@ var (
 	watchNamespace                 string
 	profilerAddress                string
 	kubeadmControlPlaneConcurrency int
+	dryRun                         bool
 	syncPeriod                     time.Duration
 	webhookPort                    int
 )
@@ -96,11 +102,17 @@ func InitFlags(fs *pflag.FlagSet) {
 	fs.IntVar(&kubeadmControlPlaneConcurrency, "kubeadmcontrolplane-concurrency", 10,
 		"Number of kubeadm control planes to process simultaneously")
 
+	fs.BoolVar(&dryRun, "dry-run", false,
+		"Log the changes the KubeadmControlPlane controller would make to the management and workload clusters, without making them. "+
+			"Dry-run mode can also be enabled for a single cluster with the cluster.x-k8s.io/dry-run annotation.")
+
 	fs.DurationVar(&syncPeriod, "sync-period", 10*time.Minute,
 		"The minimum interval at which watched resources are reconciled (e.g. 15m)")
 
 	fs.IntVar(&webhookPort, "webhook-port", 0,
 		"Webhook Server port, disabled by default. When enabled, the manager will only work as webhook server, no reconcilers are installed.")
+
+	feature.MutableGates.AddFlag(fs)
 }
 func main() {
 	rand.Seed(time.Now().UnixNano())
@@ -152,9 +164,11 @@ func setupReconcilers(mgr ctrl.Manager) {
 		return
 	}
 
+	// Changes made by the controller aren't persisted in dry-run mode, for all clusters or for the annotated ones.
 	if err := (&kubeadmcontrolplanecontrollers.KubeadmControlPlaneReconciler{
-		Client: mgr.GetClient(),
+		Client: dryrun.NewClient(mgr.GetClient(), dryRun, ctrl.Log.WithName("dry-run")),
 		Log:    ctrl.Log.WithName("controllers").WithName("KubeadmControlPlane"),
+		DryRun: dryRun,
 	}).SetupWithManager(mgr, concurrency(kubeadmControlPlaneConcurrency)); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "KubeadmControlPlane")
 		os.Exit(1)
@@ -170,6 +184,22 @@ func setupWebhooks(mgr ctrl.Manager) {
 		setupLog.Error(err, "unable to create webhook", "webhook", "KubeadmControlPlane")
 		os.Exit(1)
 	}
+
+	// Requests are allowed without further checks unless the KubernetesVersionCatalog feature is enabled.
+	if err := (&kubeadmcontrolplanewebhooks.KubernetesVersionCatalogValidator{
+		APIReader: mgr.GetAPIReader(),
+	}).SetupWebhookWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create webhook", "webhook", "KubernetesVersionCatalog")
+		os.Exit(1)
+	}
+
+	// Requests are allowed without further checks unless the MachineQuota feature is enabled.
+	if err := (&kubeadmcontrolplanewebhooks.MachineQuotaValidator{
+		APIReader: mgr.GetAPIReader(),
+	}).SetupWebhookWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create webhook", "webhook", "MachineQuota")
+		os.Exit(1)
+	}
 }
 
 func concurrency(c int) controller.Options {
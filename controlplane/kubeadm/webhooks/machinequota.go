@@ -0,0 +1,119 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhooks
+
+import (
+	"context"
+	"net/http"
+
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	controlplanev1 "sigs.k8s.io/cluster-api/controlplane/kubeadm/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/exp/quota"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+const machineQuotaWebhookPath = "/validate-controlplane-cluster-x-k8s-io-v1alpha3-kubeadmcontrolplane-machinequota"
+
+// +kubebuilder:webhook:verbs=create;update,path=/validate-controlplane-cluster-x-k8s-io-v1alpha3-kubeadmcontrolplane-machinequota,mutating=false,failurePolicy=fail,matchPolicy=Equivalent,groups=controlplane.cluster.x-k8s.io,resources=kubeadmcontrolplanes;kubeadmcontrolplanes/scale,versions=v1alpha3,name=machinequota.kubeadmcontrolplane.controlplane.cluster.x-k8s.io,sideEffects=None
+// +kubebuilder:rbac:groups=exp.cluster.x-k8s.io,resources=machinequotas,verbs=get;list
+// +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=machinesets;machinedeployments,verbs=get;list
+
+// MachineQuotaValidator denies creating a KubeadmControlPlane, or scaling it up, when its new replicas would exceed
+// the limits of the MachineQuotas applying to its Cluster.
+type MachineQuotaValidator struct {
+	// APIReader is used to compute the usage of MachineQuotas, without requiring the manager to cache the objects counted.
+	APIReader client.Reader
+
+	decoder *admission.Decoder
+}
+
+var _ admission.Handler = &MachineQuotaValidator{}
+var _ admission.DecoderInjector = &MachineQuotaValidator{}
+
+// SetupWebhookWithManager registers the MachineQuotaValidator with the manager's webhook server.
+func (v *MachineQuotaValidator) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	mgr.GetWebhookServer().Register(machineQuotaWebhookPath, &webhook.Admission{Handler: v})
+	return nil
+}
+
+// InjectDecoder implements admission.DecoderInjector.
+func (v *MachineQuotaValidator) InjectDecoder(d *admission.Decoder) error {
+	v.decoder = d
+	return nil
+}
+
+// Handle implements admission.Handler.
+func (v *MachineQuotaValidator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	var kcp *controlplanev1.KubeadmControlPlane
+	var added int32
+	if req.SubResource == "scale" {
+		scale, old := &autoscalingv1.Scale{}, &autoscalingv1.Scale{}
+		if err := v.decoder.Decode(req, scale); err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+		if err := v.decoder.DecodeRaw(req.OldObject, old); err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+		added = scale.Spec.Replicas - old.Spec.Replicas
+		if added <= 0 {
+			return admission.Allowed("")
+		}
+
+		// The Cluster isn't part of the Scale, it's read from the KubeadmControlPlane being scaled.
+		kcp = &controlplanev1.KubeadmControlPlane{}
+		if err := v.APIReader.Get(ctx, client.ObjectKey{Namespace: req.Namespace, Name: req.Name}, kcp); err != nil {
+			return admission.Errored(http.StatusInternalServerError, err)
+		}
+	} else {
+		kcp = &controlplanev1.KubeadmControlPlane{}
+		if err := v.decoder.Decode(req, kcp); err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+		added = replicas(kcp)
+		if req.Operation == admissionv1beta1.Update {
+			old := &controlplanev1.KubeadmControlPlane{}
+			if err := v.decoder.DecodeRaw(req.OldObject, old); err != nil {
+				return admission.Errored(http.StatusBadRequest, err)
+			}
+			added -= replicas(old)
+		}
+		if added <= 0 {
+			return admission.Allowed("")
+		}
+	}
+
+	reason, err := quota.CheckReplicas(ctx, v.APIReader, req.Namespace, clusterName(kcp), added)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	if reason != "" {
+		return admission.Denied(reason)
+	}
+	return admission.Allowed("")
+}
+
+// replicas returns the replicas of kcp, defaulting to 1 when unset like the KubeadmControlPlane webhook does.
+func replicas(kcp *controlplanev1.KubeadmControlPlane) int32 {
+	if kcp.Spec.Replicas == nil {
+		return 1
+	}
+	return *kcp.Spec.Replicas
+}
@@ -0,0 +1,186 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhooks
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/pointer"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	controlplanev1 "sigs.k8s.io/cluster-api/controlplane/kubeadm/api/v1alpha3"
+	expv1 "sigs.k8s.io/cluster-api/exp/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/feature"
+	"sigs.k8s.io/cluster-api/util/admissiontest"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+func TestMachineQuotaValidatorHandle(t *testing.T) {
+	withReplicas := func(kcp *controlplanev1.KubeadmControlPlane, replicas int32) *controlplanev1.KubeadmControlPlane {
+		kcp.Spec.Replicas = pointer.Int32Ptr(replicas)
+		return kcp
+	}
+	newScale := func(replicas int32) *autoscalingv1.Scale {
+		return &autoscalingv1.Scale{
+			TypeMeta:   metav1.TypeMeta{APIVersion: autoscalingv1.SchemeGroupVersion.String(), Kind: "Scale"},
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "kcp"},
+			Spec:       autoscalingv1.ScaleSpec{Replicas: replicas},
+		}
+	}
+
+	tests := []struct {
+		name      string
+		operation admissionv1beta1.Operation
+		obj       runtime.Object
+		old       runtime.Object
+		scale     bool
+		allowed   bool
+	}{
+		{
+			name:      "should allow creating a KubeadmControlPlane within the limits",
+			operation: admissionv1beta1.Create,
+			obj:       withReplicas(newKubeadmControlPlane("cluster-1", "v1.18.2"), 1),
+			allowed:   true,
+		},
+		{
+			name:      "should deny creating a KubeadmControlPlane exceeding maxMachines",
+			operation: admissionv1beta1.Create,
+			obj:       withReplicas(newKubeadmControlPlane("cluster-1", "v1.18.2"), 3),
+			allowed:   false,
+		},
+		{
+			name:      "should allow scaling up a KubeadmControlPlane within the limits",
+			operation: admissionv1beta1.Update,
+			obj:       withReplicas(newKubeadmControlPlane("cluster-1", "v1.18.2"), 3),
+			old:       withReplicas(newKubeadmControlPlane("cluster-1", "v1.18.2"), 1),
+			allowed:   true,
+		},
+		{
+			name:      "should deny scaling up a KubeadmControlPlane exceeding the limits",
+			operation: admissionv1beta1.Update,
+			obj:       withReplicas(newKubeadmControlPlane("cluster-1", "v1.18.2"), 5),
+			old:       withReplicas(newKubeadmControlPlane("cluster-1", "v1.18.2"), 1),
+			allowed:   false,
+		},
+		{
+			name:      "should allow scaling up a KubeadmControlPlane of a Cluster the quota doesn't apply to",
+			operation: admissionv1beta1.Update,
+			obj:       withReplicas(newKubeadmControlPlane("cluster-2", "v1.18.2"), 5),
+			old:       withReplicas(newKubeadmControlPlane("cluster-2", "v1.18.2"), 1),
+			allowed:   true,
+		},
+		{
+			name:      "should allow scaling up a KubeadmControlPlane through its scale subresource within the limits",
+			operation: admissionv1beta1.Update,
+			obj:       newScale(3),
+			old:       newScale(1),
+			scale:     true,
+			allowed:   true,
+		},
+		{
+			name:      "should deny scaling up a KubeadmControlPlane through its scale subresource exceeding the limits",
+			operation: admissionv1beta1.Update,
+			obj:       newScale(5),
+			old:       newScale(1),
+			scale:     true,
+			allowed:   false,
+		},
+		{
+			name:      "should allow scaling down a KubeadmControlPlane through its scale subresource",
+			operation: admissionv1beta1.Update,
+			obj:       newScale(1),
+			old:       newScale(3),
+			scale:     true,
+			allowed:   true,
+		},
+	}
+
+	defer func() {
+		_ = feature.MutableGates.Set("MachineQuota=false")
+	}()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			scheme := runtime.NewScheme()
+			g.Expect(autoscalingv1.AddToScheme(scheme)).To(Succeed())
+			g.Expect(clusterv1.AddToScheme(scheme)).To(Succeed())
+			g.Expect(controlplanev1.AddToScheme(scheme)).To(Succeed())
+			g.Expect(expv1.AddToScheme(scheme)).To(Succeed())
+
+			quota := &expv1.MachineQuota{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "quota"},
+				Spec: expv1.MachineQuotaSpec{
+					ClusterNames:          []string{"cluster-1"},
+					MaxMachines:           pointer.Int32Ptr(4),
+					MaxReplicasPerCluster: pointer.Int32Ptr(5),
+				},
+			}
+			cluster := &clusterv1.Cluster{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "cluster-1"},
+				Spec: clusterv1.ClusterSpec{
+					ControlPlaneRef: &corev1.ObjectReference{
+						APIVersion: controlplanev1.GroupVersion.String(),
+						Kind:       "KubeadmControlPlane",
+						Namespace:  "default",
+						Name:       "kcp",
+					},
+				},
+			}
+			machines := make([]runtime.Object, 0, 2)
+			for _, name := range []string{"m-1", "m-2"} {
+				machines = append(machines, &clusterv1.Machine{
+					ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name},
+					Spec:       clusterv1.MachineSpec{ClusterName: "cluster-1"},
+				})
+			}
+			md := &clusterv1.MachineDeployment{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "md"},
+				Spec:       clusterv1.MachineDeploymentSpec{ClusterName: "cluster-1", Replicas: pointer.Int32Ptr(2)},
+			}
+			objs := append([]runtime.Object{quota, cluster, md, withReplicas(newKubeadmControlPlane("cluster-1", "v1.18.2"), 1)}, machines...)
+
+			decoder, err := admission.NewDecoder(scheme)
+			g.Expect(err).NotTo(HaveOccurred())
+			v := &MachineQuotaValidator{APIReader: fake.NewFakeClientWithScheme(scheme, objs...)}
+			g.Expect(v.InjectDecoder(decoder)).To(Succeed())
+
+			req, err := admissiontest.NewRequest(tt.operation, "default", tt.obj, tt.old)
+			g.Expect(err).NotTo(HaveOccurred())
+			if tt.scale {
+				req.Name = "kcp"
+				req.Resource = metav1.GroupVersionResource{Group: controlplanev1.GroupVersion.Group, Version: controlplanev1.GroupVersion.Version, Resource: "kubeadmcontrolplanes"}
+				req.SubResource = "scale"
+			}
+
+			g.Expect(feature.MutableGates.Set("MachineQuota=false")).To(Succeed())
+			g.Expect(v.Handle(context.Background(), req).Allowed).To(BeTrue())
+
+			g.Expect(feature.MutableGates.Set("MachineQuota=true")).To(Succeed())
+			g.Expect(v.Handle(context.Background(), req).Allowed).To(Equal(tt.allowed))
+		})
+	}
+}
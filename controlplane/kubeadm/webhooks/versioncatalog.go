@@ -0,0 +1,99 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package webhooks implements the admission webhooks of the kubeadm control plane provider
+// that need to read other objects, as opposed to the ones implemented on the API types.
+package webhooks
+
+import (
+	"context"
+	"net/http"
+
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	controlplanev1 "sigs.k8s.io/cluster-api/controlplane/kubeadm/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/exp/versioncatalog"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+const kubernetesVersionCatalogWebhookPath = "/validate-controlplane-cluster-x-k8s-io-v1alpha3-kubeadmcontrolplane-kubernetesversioncatalog"
+
+// +kubebuilder:webhook:verbs=create;update,path=/validate-controlplane-cluster-x-k8s-io-v1alpha3-kubeadmcontrolplane-kubernetesversioncatalog,mutating=false,failurePolicy=fail,matchPolicy=Equivalent,groups=controlplane.cluster.x-k8s.io,resources=kubeadmcontrolplanes,versions=v1alpha3,name=kubernetesversioncatalog.kubeadmcontrolplane.controlplane.cluster.x-k8s.io,sideEffects=None
+// +kubebuilder:rbac:groups=exp.cluster.x-k8s.io,resources=kubernetesversioncatalogs,verbs=get;list
+
+// KubernetesVersionCatalogValidator denies creating a KubeadmControlPlane, or upgrading it, to a Kubernetes version
+// that is not available from the KubernetesVersionCatalogs applying to its Cluster.
+type KubernetesVersionCatalogValidator struct {
+	// APIReader is used to read KubernetesVersionCatalogs, without requiring the manager to cache them.
+	APIReader client.Reader
+
+	decoder *admission.Decoder
+}
+
+var _ admission.Handler = &KubernetesVersionCatalogValidator{}
+var _ admission.DecoderInjector = &KubernetesVersionCatalogValidator{}
+
+// SetupWebhookWithManager registers the KubernetesVersionCatalogValidator with the manager's webhook server.
+func (v *KubernetesVersionCatalogValidator) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	mgr.GetWebhookServer().Register(kubernetesVersionCatalogWebhookPath, &webhook.Admission{Handler: v})
+	return nil
+}
+
+// InjectDecoder implements admission.DecoderInjector.
+func (v *KubernetesVersionCatalogValidator) InjectDecoder(d *admission.Decoder) error {
+	v.decoder = d
+	return nil
+}
+
+// Handle implements admission.Handler.
+func (v *KubernetesVersionCatalogValidator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	kcp := &controlplanev1.KubeadmControlPlane{}
+	if err := v.decoder.Decode(req, kcp); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	if req.Operation == admissionv1beta1.Update {
+		old := &controlplanev1.KubeadmControlPlane{}
+		if err := v.decoder.DecodeRaw(req.OldObject, old); err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+		if old.Spec.Version == kcp.Spec.Version {
+			return admission.Allowed("")
+		}
+	}
+
+	reason, err := versioncatalog.CheckVersion(ctx, v.APIReader, req.Namespace, clusterName(kcp), kcp.Spec.Version)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	if reason != "" {
+		return admission.Denied(reason)
+	}
+	return admission.Allowed("")
+}
+
+// clusterName returns the name of the Cluster owning kcp, falling back to the cluster name label
+// for KubeadmControlPlanes not adopted by their Cluster yet.
+func clusterName(kcp *controlplanev1.KubeadmControlPlane) string {
+	for _, ref := range kcp.OwnerReferences {
+		if ref.Kind == "Cluster" && ref.APIVersion == clusterv1.GroupVersion.String() {
+			return ref.Name
+		}
+	}
+	return kcp.Labels[clusterv1.ClusterLabelName]
+}
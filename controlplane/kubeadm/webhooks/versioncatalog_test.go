@@ -0,0 +1,139 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhooks
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	controlplanev1 "sigs.k8s.io/cluster-api/controlplane/kubeadm/api/v1alpha3"
+	expv1 "sigs.k8s.io/cluster-api/exp/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/feature"
+	"sigs.k8s.io/cluster-api/util/admissiontest"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+func newKubeadmControlPlane(clusterName, version string) *controlplanev1.KubeadmControlPlane {
+	return &controlplanev1.KubeadmControlPlane{
+		TypeMeta: metav1.TypeMeta{APIVersion: controlplanev1.GroupVersion.String(), Kind: "KubeadmControlPlane"},
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "default",
+			Name:      "kcp",
+			OwnerReferences: []metav1.OwnerReference{
+				{APIVersion: clusterv1.GroupVersion.String(), Kind: "Cluster", Name: clusterName},
+			},
+		},
+		Spec: controlplanev1.KubeadmControlPlaneSpec{Version: version},
+	}
+}
+
+func TestKubernetesVersionCatalogValidatorHandle(t *testing.T) {
+	tests := []struct {
+		name      string
+		operation admissionv1beta1.Operation
+		obj       runtime.Object
+		old       runtime.Object
+		allowed   bool
+	}{
+		{
+			name:      "should allow creating a KubeadmControlPlane with an available version",
+			operation: admissionv1beta1.Create,
+			obj:       newKubeadmControlPlane("cluster-1", "v1.18.2"),
+			allowed:   true,
+		},
+		{
+			name:      "should deny creating a KubeadmControlPlane with a version that isn't available",
+			operation: admissionv1beta1.Create,
+			obj:       newKubeadmControlPlane("cluster-1", "v1.19.0"),
+			allowed:   false,
+		},
+		{
+			name:      "should allow upgrading a KubeadmControlPlane to an available version",
+			operation: admissionv1beta1.Update,
+			obj:       newKubeadmControlPlane("cluster-1", "v1.18.2"),
+			old:       newKubeadmControlPlane("cluster-1", "v1.17.5"),
+			allowed:   true,
+		},
+		{
+			name:      "should deny upgrading a KubeadmControlPlane to a version that isn't available",
+			operation: admissionv1beta1.Update,
+			obj:       newKubeadmControlPlane("cluster-1", "v1.19.0"),
+			old:       newKubeadmControlPlane("cluster-1", "v1.18.2"),
+			allowed:   false,
+		},
+		{
+			name:      "should allow updating a KubeadmControlPlane without changing its version",
+			operation: admissionv1beta1.Update,
+			obj:       newKubeadmControlPlane("cluster-1", "v1.16.0"),
+			old:       newKubeadmControlPlane("cluster-1", "v1.16.0"),
+			allowed:   true,
+		},
+		{
+			name:      "should allow upgrading a KubeadmControlPlane of a Cluster the catalog doesn't apply to",
+			operation: admissionv1beta1.Update,
+			obj:       newKubeadmControlPlane("cluster-2", "v1.19.0"),
+			old:       newKubeadmControlPlane("cluster-2", "v1.18.2"),
+			allowed:   true,
+		},
+	}
+
+	defer func() {
+		_ = feature.MutableGates.Set("KubernetesVersionCatalog=false")
+	}()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			scheme := runtime.NewScheme()
+			g.Expect(controlplanev1.AddToScheme(scheme)).To(Succeed())
+			g.Expect(expv1.AddToScheme(scheme)).To(Succeed())
+
+			catalog := &expv1.KubernetesVersionCatalog{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "catalog"},
+				Spec: expv1.KubernetesVersionCatalogSpec{
+					ClusterNames: []string{"cluster-1"},
+				},
+				Status: expv1.KubernetesVersionCatalogStatus{
+					Ready:    true,
+					Versions: []string{"v1.17.5", "v1.18.2"},
+				},
+			}
+
+			decoder, err := admission.NewDecoder(scheme)
+			g.Expect(err).NotTo(HaveOccurred())
+			v := &KubernetesVersionCatalogValidator{APIReader: fake.NewFakeClientWithScheme(scheme, catalog)}
+			g.Expect(v.InjectDecoder(decoder)).To(Succeed())
+
+			req, err := admissiontest.NewRequest(tt.operation, "default", tt.obj, tt.old)
+			g.Expect(err).NotTo(HaveOccurred())
+
+			g.Expect(feature.MutableGates.Set("KubernetesVersionCatalog=false")).To(Succeed())
+			g.Expect(v.Handle(context.Background(), req).Allowed).To(BeTrue())
+
+			g.Expect(feature.MutableGates.Set("KubernetesVersionCatalog=true")).To(Succeed())
+			g.Expect(v.Handle(context.Background(), req).Allowed).To(Equal(tt.allowed))
+		})
+	}
+}
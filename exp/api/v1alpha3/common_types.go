@@ -0,0 +1,31 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha3
+
+// selectsCluster returns true if clusterNames, as found in the spec of exp types applying to a set of Clusters,
+// selects the Cluster with the given name. An empty list selects all the Clusters.
+func selectsCluster(clusterNames []string, clusterName string) bool {
+	if len(clusterNames) == 0 {
+		return true
+	}
+	for _, name := range clusterNames {
+		if name == clusterName {
+			return true
+		}
+	}
+	return false
+}
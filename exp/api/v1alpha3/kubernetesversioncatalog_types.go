@@ -0,0 +1,115 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha3
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ANCHOR: KubernetesVersionCatalogSpec
+
+// KubernetesVersionCatalogSpec defines the desired state of KubernetesVersionCatalog
+type KubernetesVersionCatalogSpec struct {
+	// ClusterNames is the list of Clusters in the namespace of the KubernetesVersionCatalog
+	// whose Kubernetes version must be available from the catalog.
+	// If empty, the catalog applies to all the Clusters in the namespace.
+	// +optional
+	ClusterNames []string `json:"clusterNames,omitempty"`
+
+	// Sources is the list of objects, typically provided by infrastructure providers
+	// (e.g. a catalog of machine images), to discover available Kubernetes versions from.
+	// Each source is expected to report the Kubernetes versions it offers in `status.kubernetesVersions`.
+	// +kubebuilder:validation:MinItems=1
+	Sources []corev1.ObjectReference `json:"sources"`
+}
+
+// ANCHOR_END: KubernetesVersionCatalogSpec
+
+// ANCHOR: KubernetesVersionCatalogStatus
+
+// KubernetesVersionCatalogStatus defines the observed state of KubernetesVersionCatalog
+type KubernetesVersionCatalogStatus struct {
+	// Ready is true when the Kubernetes versions have been discovered from all the sources.
+	// +optional
+	Ready bool `json:"ready"`
+
+	// Versions is the list of Kubernetes versions available from all the sources.
+	// +optional
+	Versions []string `json:"versions,omitempty"`
+
+	// Sources is the list of Kubernetes versions discovered from each source.
+	// +optional
+	Sources []KubernetesVersionSource `json:"sources,omitempty"`
+
+	// FailureMessage indicates that there is a problem discovering
+	// Kubernetes versions from one of the sources.
+	// +optional
+	FailureMessage *string `json:"failureMessage,omitempty"`
+
+	// ObservedGeneration is the latest generation observed by the controller.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+}
+
+// KubernetesVersionSource is the list of Kubernetes versions discovered from a single source.
+type KubernetesVersionSource struct {
+	// Ref is a reference to the source.
+	Ref corev1.ObjectReference `json:"ref"`
+
+	// Versions is the list of Kubernetes versions reported by the source.
+	// +optional
+	Versions []string `json:"versions,omitempty"`
+}
+
+// ANCHOR_END: KubernetesVersionCatalogStatus
+
+// AppliesTo returns true if the Kubernetes version of the Cluster with the given name
+// must be available from the KubernetesVersionCatalog.
+func (c *KubernetesVersionCatalog) AppliesTo(clusterName string) bool {
+	return selectsCluster(c.Spec.ClusterNames, clusterName)
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:path=kubernetesversioncatalogs,shortName=kvc,scope=Namespaced,categories=cluster-api
+// +kubebuilder:subresource:status
+// +kubebuilder:storageversion
+// +kubebuilder:printcolumn:name="Ready",type="boolean",JSONPath=".status.ready",description="Kubernetes versions have been discovered from all the sources"
+// +kubebuilder:printcolumn:name="Versions",type="string",JSONPath=".status.versions",description="Kubernetes versions available from all the sources"
+// +k8s:conversion-gen=false
+
+// KubernetesVersionCatalog is the Schema for the kubernetesversioncatalogs API
+type KubernetesVersionCatalog struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   KubernetesVersionCatalogSpec   `json:"spec,omitempty"`
+	Status KubernetesVersionCatalogStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// KubernetesVersionCatalogList contains a list of KubernetesVersionCatalog
+type KubernetesVersionCatalogList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []KubernetesVersionCatalog `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&KubernetesVersionCatalog{}, &KubernetesVersionCatalogList{})
+}
@@ -0,0 +1,108 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha3
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ANCHOR: MachineQuotaSpec
+
+// MachineQuotaSpec defines the desired state of MachineQuota
+type MachineQuotaSpec struct {
+	// ClusterNames is the list of Clusters in the namespace of the MachineQuota the limits apply to.
+	// If empty, the limits apply to all the Clusters in the namespace.
+	// +optional
+	ClusterNames []string `json:"clusterNames,omitempty"`
+
+	// MaxMachines is the maximum number of Machines that can exist in the namespace
+	// for the selected Clusters.
+	// Machines created by a controller, e.g. a MachineSet or a control plane provider, are counted
+	// but never denied, so that rollouts and remediation can replace existing Machines;
+	// instead, scaling up MachineDeployments, MachineSets and control planes is denied
+	// when the new replicas would exceed MaxMachines.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxMachines *int32 `json:"maxMachines,omitempty"`
+
+	// MaxReplicasPerCluster is the maximum number of replicas that can be requested for each
+	// of the selected Clusters, summed across its MachineDeployments, the MachineSets not
+	// managed by a MachineDeployment and its control plane.
+	// The replicas of a control plane are read from its spec.replicas field; they are only
+	// limited if the control plane provider checks them, as the kubeadm control plane provider does.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxReplicasPerCluster *int32 `json:"maxReplicasPerCluster,omitempty"`
+}
+
+// ANCHOR_END: MachineQuotaSpec
+
+// ANCHOR: MachineQuotaStatus
+
+// MachineQuotaStatus defines the observed state of MachineQuota
+type MachineQuotaStatus struct {
+	// Machines is the most recently observed number of Machines counted against MaxMachines.
+	// +optional
+	Machines int32 `json:"machines"`
+
+	// ClusterReplicas is the most recently observed number of replicas requested for each
+	// of the selected Clusters, counted against MaxReplicasPerCluster.
+	// +optional
+	ClusterReplicas map[string]int32 `json:"clusterReplicas,omitempty"`
+
+	// ObservedGeneration is the latest generation observed by the controller.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+}
+
+// ANCHOR_END: MachineQuotaStatus
+
+// AppliesTo returns true if the MachineQuota limits apply to the Cluster with the given name.
+func (q *MachineQuota) AppliesTo(clusterName string) bool {
+	return selectsCluster(q.Spec.ClusterNames, clusterName)
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:path=machinequotas,shortName=mq,scope=Namespaced,categories=cluster-api
+// +kubebuilder:subresource:status
+// +kubebuilder:storageversion
+// +kubebuilder:printcolumn:name="MaxMachines",type="integer",JSONPath=".spec.maxMachines",description="Maximum number of Machines"
+// +kubebuilder:printcolumn:name="Machines",type="integer",JSONPath=".status.machines",description="Observed number of Machines"
+// +kubebuilder:printcolumn:name="MaxReplicasPerCluster",type="integer",JSONPath=".spec.maxReplicasPerCluster",description="Maximum number of replicas for each Cluster"
+// +k8s:conversion-gen=false
+
+// MachineQuota is the Schema for the machinequotas API
+type MachineQuota struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   MachineQuotaSpec   `json:"spec,omitempty"`
+	Status MachineQuotaStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// MachineQuotaList contains a list of MachineQuota
+type MachineQuotaList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []MachineQuota `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&MachineQuota{}, &MachineQuotaList{})
+}
@@ -0,0 +1,110 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
+	expv1 "sigs.k8s.io/cluster-api/exp/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/exp/versioncatalog"
+	"sigs.k8s.io/cluster-api/util/patch"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+)
+
+// +kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=*,verbs=get;list;watch
+// +kubebuilder:rbac:groups=exp.cluster.x-k8s.io,resources=kubernetesversioncatalogs;kubernetesversioncatalogs/status,verbs=get;list;watch;update;patch
+
+const (
+	// DefaultKubernetesVersionCatalogRefreshPeriod is the default interval at which
+	// Kubernetes versions are discovered again from the sources of a KubernetesVersionCatalog.
+	DefaultKubernetesVersionCatalogRefreshPeriod = 10 * time.Minute
+)
+
+// KubernetesVersionCatalogReconciler reconciles a KubernetesVersionCatalog object
+type KubernetesVersionCatalogReconciler struct {
+	Client client.Client
+	Log    logr.Logger
+
+	// RefreshPeriod is the interval at which Kubernetes versions are discovered again from the sources.
+	// Sources aren't watched, as they can be of any kind. Defaults to DefaultKubernetesVersionCatalogRefreshPeriod.
+	RefreshPeriod time.Duration
+}
+
+func (r *KubernetesVersionCatalogReconciler) SetupWithManager(mgr ctrl.Manager, options controller.Options) error {
+	_, err := ctrl.NewControllerManagedBy(mgr).
+		For(&expv1.KubernetesVersionCatalog{}).
+		WithOptions(options).
+		Build(r)
+	if err != nil {
+		return errors.Wrap(err, "failed setting up with a controller manager")
+	}
+	return nil
+}
+
+func (r *KubernetesVersionCatalogReconciler) Reconcile(req ctrl.Request) (_ ctrl.Result, reterr error) {
+	ctx := context.Background()
+	logger := r.Log.WithValues("kubernetesversioncatalog", req.Name, "namespace", req.Namespace)
+
+	catalog := &expv1.KubernetesVersionCatalog{}
+	if err := r.Client.Get(ctx, req.NamespacedName, catalog); err != nil {
+		if apierrors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		logger.Error(err, "Failed to fetch KubernetesVersionCatalog")
+		return ctrl.Result{}, err
+	}
+
+	// Initialize the patch helper.
+	patchHelper, err := patch.NewHelper(catalog, r.Client)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	defer func() {
+		// Always attempt to patch the object and status after each reconciliation.
+		if err := patchHelper.Patch(ctx, catalog); err != nil {
+			reterr = kerrors.NewAggregate([]error{reterr, err})
+		}
+	}()
+
+	sources, err := versioncatalog.Discover(ctx, r.Client, catalog)
+
+	catalog.Status.Sources = sources
+	catalog.Status.Versions = versioncatalog.Available(sources)
+	catalog.Status.ObservedGeneration = catalog.Generation
+	catalog.Status.Ready = err == nil
+	catalog.Status.FailureMessage = nil
+	if err != nil {
+		logger.Error(err, "Failed to discover Kubernetes versions")
+		msg := err.Error()
+		catalog.Status.FailureMessage = &msg
+		return ctrl.Result{}, err
+	}
+
+	refreshPeriod := r.RefreshPeriod
+	if refreshPeriod <= 0 {
+		refreshPeriod = DefaultKubernetesVersionCatalogRefreshPeriod
+	}
+	return ctrl.Result{RequeueAfter: refreshPeriod}, nil
+}
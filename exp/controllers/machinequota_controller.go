@@ -0,0 +1,132 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	expv1 "sigs.k8s.io/cluster-api/exp/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/exp/quota"
+	"sigs.k8s.io/cluster-api/util/patch"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+// +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=clusters;machines;machinesets;machinedeployments,verbs=get;list;watch
+// +kubebuilder:rbac:groups=exp.cluster.x-k8s.io,resources=machinequotas;machinequotas/status,verbs=get;list;watch;update;patch
+
+// MachineQuotaReconciler reconciles a MachineQuota object
+type MachineQuotaReconciler struct {
+	Client client.Client
+	Log    logr.Logger
+}
+
+func (r *MachineQuotaReconciler) SetupWithManager(mgr ctrl.Manager, options controller.Options) error {
+	toMachineQuotas := &handler.EnqueueRequestsFromMapFunc{ToRequests: handler.ToRequestsFunc(r.objectToMachineQuotas)}
+
+	_, err := ctrl.NewControllerManagedBy(mgr).
+		For(&expv1.MachineQuota{}).
+		Watches(&source.Kind{Type: &clusterv1.Machine{}}, toMachineQuotas).
+		Watches(&source.Kind{Type: &clusterv1.MachineSet{}}, toMachineQuotas).
+		Watches(&source.Kind{Type: &clusterv1.MachineDeployment{}}, toMachineQuotas).
+		WithOptions(options).
+		Build(r)
+	if err != nil {
+		return errors.Wrap(err, "failed setting up with a controller manager")
+	}
+	return nil
+}
+
+func (r *MachineQuotaReconciler) Reconcile(req ctrl.Request) (_ ctrl.Result, reterr error) {
+	ctx := context.Background()
+	logger := r.Log.WithValues("machinequota", req.Name, "namespace", req.Namespace)
+
+	mq := &expv1.MachineQuota{}
+	if err := r.Client.Get(ctx, req.NamespacedName, mq); err != nil {
+		if apierrors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		logger.Error(err, "Failed to fetch MachineQuota")
+		return ctrl.Result{}, err
+	}
+
+	// Initialize the patch helper.
+	patchHelper, err := patch.NewHelper(mq, r.Client)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	defer func() {
+		// Always attempt to patch the object and status after each reconciliation.
+		if err := patchHelper.Patch(ctx, mq); err != nil {
+			reterr = kerrors.NewAggregate([]error{reterr, err})
+		}
+	}()
+
+	usage, err := quota.ComputeUsage(ctx, r.Client, mq)
+	if err != nil {
+		logger.Error(err, "Failed to compute MachineQuota usage")
+		return ctrl.Result{}, err
+	}
+
+	mq.Status.Machines = usage.Machines
+	mq.Status.ClusterReplicas = usage.ClusterReplicas
+	mq.Status.ObservedGeneration = mq.Generation
+
+	return ctrl.Result{}, nil
+}
+
+// objectToMachineQuotas maps events from Machines, MachineSets and MachineDeployments to the
+// MachineQuotas in the same namespace that apply to their Cluster.
+func (r *MachineQuotaReconciler) objectToMachineQuotas(o handler.MapObject) []ctrl.Request {
+	var clusterName string
+	switch obj := o.Object.(type) {
+	case *clusterv1.Machine:
+		clusterName = obj.Spec.ClusterName
+	case *clusterv1.MachineSet:
+		clusterName = obj.Spec.ClusterName
+	case *clusterv1.MachineDeployment:
+		clusterName = obj.Spec.ClusterName
+	default:
+		return nil
+	}
+
+	quotas := &expv1.MachineQuotaList{}
+	if err := r.Client.List(context.Background(), quotas, client.InNamespace(o.Meta.GetNamespace())); err != nil {
+		r.Log.Error(err, "Failed to list MachineQuotas", "namespace", o.Meta.GetNamespace())
+		return nil
+	}
+
+	requests := []ctrl.Request{}
+	for i := range quotas.Items {
+		if !quotas.Items[i].AppliesTo(clusterName) {
+			continue
+		}
+		requests = append(requests, ctrl.Request{
+			NamespacedName: client.ObjectKey{Namespace: quotas.Items[i].Namespace, Name: quotas.Items[i].Name},
+		})
+	}
+	return requests
+}
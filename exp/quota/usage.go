@@ -0,0 +1,137 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package quota
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	expv1 "sigs.k8s.io/cluster-api/exp/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/util"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Usage is the usage counted against the limits of a MachineQuota.
+type Usage struct {
+	// Machines is the number of Machines belonging to the Clusters selected by the MachineQuota.
+	Machines int32
+
+	// ClusterReplicas is the number of replicas requested for each of the Clusters selected by the MachineQuota,
+	// including the replicas of their control plane.
+	ClusterReplicas map[string]int32
+}
+
+// ComputeUsage computes the usage counted against the limits of quota.
+// Objects being deleted are not counted.
+func ComputeUsage(ctx context.Context, c client.Reader, quota *expv1.MachineQuota) (*Usage, error) {
+	usage := &Usage{
+		ClusterReplicas: make(map[string]int32),
+	}
+
+	machines := &clusterv1.MachineList{}
+	if err := c.List(ctx, machines, client.InNamespace(quota.Namespace)); err != nil {
+		return nil, errors.Wrapf(err, "failed to list Machines in namespace %q", quota.Namespace)
+	}
+	for i := range machines.Items {
+		m := &machines.Items[i]
+		if !m.DeletionTimestamp.IsZero() || !quota.AppliesTo(m.Spec.ClusterName) {
+			continue
+		}
+		usage.Machines++
+	}
+
+	machineDeployments := &clusterv1.MachineDeploymentList{}
+	if err := c.List(ctx, machineDeployments, client.InNamespace(quota.Namespace)); err != nil {
+		return nil, errors.Wrapf(err, "failed to list MachineDeployments in namespace %q", quota.Namespace)
+	}
+	for i := range machineDeployments.Items {
+		md := &machineDeployments.Items[i]
+		if !md.DeletionTimestamp.IsZero() || !quota.AppliesTo(md.Spec.ClusterName) {
+			continue
+		}
+		usage.ClusterReplicas[md.Spec.ClusterName] += replicas(md.Spec.Replicas)
+	}
+
+	machineSets := &clusterv1.MachineSetList{}
+	if err := c.List(ctx, machineSets, client.InNamespace(quota.Namespace)); err != nil {
+		return nil, errors.Wrapf(err, "failed to list MachineSets in namespace %q", quota.Namespace)
+	}
+	for i := range machineSets.Items {
+		ms := &machineSets.Items[i]
+		if !ms.DeletionTimestamp.IsZero() || !quota.AppliesTo(ms.Spec.ClusterName) || isManagedByMachineDeployment(ms) {
+			continue
+		}
+		usage.ClusterReplicas[ms.Spec.ClusterName] += replicas(ms.Spec.Replicas)
+	}
+
+	clusters := &clusterv1.ClusterList{}
+	if err := c.List(ctx, clusters, client.InNamespace(quota.Namespace)); err != nil {
+		return nil, errors.Wrapf(err, "failed to list Clusters in namespace %q", quota.Namespace)
+	}
+	for i := range clusters.Items {
+		cluster := &clusters.Items[i]
+		if !cluster.DeletionTimestamp.IsZero() || !quota.AppliesTo(cluster.Name) || cluster.Spec.ControlPlaneRef == nil {
+			continue
+		}
+		r, err := controlPlaneReplicas(ctx, c, cluster)
+		if err != nil {
+			return nil, err
+		}
+		usage.ClusterReplicas[cluster.Name] += r
+	}
+
+	return usage, nil
+}
+
+// controlPlaneReplicas returns the replicas of the control plane referenced by cluster, read from its spec.replicas field.
+// Control planes that don't exist yet, or don't have replicas, have none.
+func controlPlaneReplicas(ctx context.Context, c client.Reader, cluster *clusterv1.Cluster) (int32, error) {
+	ref := cluster.Spec.ControlPlaneRef
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion(ref.APIVersion)
+	obj.SetKind(ref.Kind)
+	if err := c.Get(ctx, client.ObjectKey{Namespace: cluster.Namespace, Name: ref.Name}, obj); err != nil {
+		if apierrors.IsNotFound(err) {
+			return 0, nil
+		}
+		return 0, errors.Wrapf(err, "failed to get %s %q for Cluster %q in namespace %q", ref.Kind, ref.Name, cluster.Name, cluster.Namespace)
+	}
+	r, found, err := unstructured.NestedInt64(obj.Object, "spec", "replicas")
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to read replicas of %s %q", ref.Kind, ref.Name)
+	}
+	if !found {
+		return 0, nil
+	}
+	return int32(r), nil
+}
+
+// isManagedByMachineDeployment returns true if the replicas of the MachineSet are accounted for by a MachineDeployment.
+func isManagedByMachineDeployment(ms *clusterv1.MachineSet) bool {
+	return util.HasOwner(ms.OwnerReferences, clusterv1.GroupVersion.String(), []string{"MachineDeployment"})
+}
+
+// replicas returns the number of replicas, defaulting to 1 when unset like the MachineSet and MachineDeployment webhooks do.
+func replicas(r *int32) int32 {
+	if r == nil {
+		return 1
+	}
+	return *r
+}
@@ -0,0 +1,134 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package quota
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/pointer"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	expv1 "sigs.k8s.io/cluster-api/exp/api/v1alpha3"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func newScheme(g *WithT) *runtime.Scheme {
+	scheme := runtime.NewScheme()
+	g.Expect(clusterv1.AddToScheme(scheme)).To(Succeed())
+	g.Expect(expv1.AddToScheme(scheme)).To(Succeed())
+	g.Expect(autoscalingv1.AddToScheme(scheme)).To(Succeed())
+	return scheme
+}
+
+func newCluster(namespace, name, controlPlaneName string) *clusterv1.Cluster {
+	cluster := &clusterv1.Cluster{
+		TypeMeta:   metav1.TypeMeta{APIVersion: clusterv1.GroupVersion.String(), Kind: "Cluster"},
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+	}
+	if controlPlaneName != "" {
+		cluster.Spec.ControlPlaneRef = &corev1.ObjectReference{
+			APIVersion: "controlplane.cluster.x-k8s.io/v1alpha3",
+			Kind:       "KubeadmControlPlane",
+			Namespace:  namespace,
+			Name:       controlPlaneName,
+		}
+	}
+	return cluster
+}
+
+func newMachine(namespace, name, clusterName string) *clusterv1.Machine {
+	return &clusterv1.Machine{
+		TypeMeta:   metav1.TypeMeta{APIVersion: clusterv1.GroupVersion.String(), Kind: "Machine"},
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+		Spec:       clusterv1.MachineSpec{ClusterName: clusterName},
+	}
+}
+
+func newMachineSet(namespace, name, clusterName string, replicas int32) *clusterv1.MachineSet {
+	return &clusterv1.MachineSet{
+		TypeMeta:   metav1.TypeMeta{APIVersion: clusterv1.GroupVersion.String(), Kind: "MachineSet"},
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+		Spec:       clusterv1.MachineSetSpec{ClusterName: clusterName, Replicas: pointer.Int32Ptr(replicas)},
+	}
+}
+
+func newMachineDeployment(namespace, name, clusterName string, replicas int32) *clusterv1.MachineDeployment {
+	return &clusterv1.MachineDeployment{
+		TypeMeta:   metav1.TypeMeta{APIVersion: clusterv1.GroupVersion.String(), Kind: "MachineDeployment"},
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+		Spec:       clusterv1.MachineDeploymentSpec{ClusterName: clusterName, Replicas: pointer.Int32Ptr(replicas)},
+	}
+}
+
+func TestComputeUsage(t *testing.T) {
+	g := NewWithT(t)
+
+	deletedMachine := newMachine("default", "deleted", "cluster-1")
+	now := metav1.Now()
+	deletedMachine.DeletionTimestamp = &now
+
+	managedMachineSet := newMachineSet("default", "managed", "cluster-1", 3)
+	managedMachineSet.OwnerReferences = []metav1.OwnerReference{
+		{APIVersion: clusterv1.GroupVersion.String(), Kind: "MachineDeployment", Name: "md-1"},
+	}
+
+	controlPlane := &unstructured.Unstructured{}
+	controlPlane.SetAPIVersion("controlplane.cluster.x-k8s.io/v1alpha3")
+	controlPlane.SetKind("KubeadmControlPlane")
+	controlPlane.SetNamespace("default")
+	controlPlane.SetName("cp-1")
+	g.Expect(unstructured.SetNestedField(controlPlane.Object, int64(3), "spec", "replicas")).To(Succeed())
+
+	c := fake.NewFakeClientWithScheme(newScheme(g),
+		newCluster("default", "cluster-1", "cp-1"),
+		newCluster("default", "cluster-2", "cp-2"),
+		newCluster("default", "cluster-3", ""),
+		controlPlane,
+		newMachine("default", "m-1", "cluster-1"),
+		newMachine("default", "m-2", "cluster-2"),
+		newMachine("default", "m-3", "cluster-3"),
+		newMachine("other", "m-4", "cluster-1"),
+		deletedMachine,
+		newMachineDeployment("default", "md-1", "cluster-1", 3),
+		newMachineDeployment("default", "md-2", "cluster-2", 2),
+		managedMachineSet,
+		newMachineSet("default", "ms-1", "cluster-1", 1),
+		newMachineSet("other", "ms-2", "cluster-1", 5),
+	)
+
+	quota := &expv1.MachineQuota{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "quota"},
+		Spec: expv1.MachineQuotaSpec{
+			ClusterNames: []string{"cluster-1", "cluster-2"},
+		},
+	}
+
+	usage, err := ComputeUsage(context.Background(), c, quota)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(usage.Machines).To(Equal(int32(2)))
+	g.Expect(usage.ClusterReplicas).To(Equal(map[string]int32{
+		"cluster-1": 7,
+		"cluster-2": 2,
+	}))
+}
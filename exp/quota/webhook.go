@@ -0,0 +1,264 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package quota
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	expv1 "sigs.k8s.io/cluster-api/exp/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/feature"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+const webhookPath = "/validate-exp-cluster-x-k8s-io-v1alpha3-machinequota-admission"
+
+// +kubebuilder:webhook:verbs=create;update,path=/validate-exp-cluster-x-k8s-io-v1alpha3-machinequota-admission,mutating=false,failurePolicy=fail,matchPolicy=Equivalent,groups=cluster.x-k8s.io,resources=machines;machinesets;machinedeployments;machinesets/scale;machinedeployments/scale,versions=v1alpha3,name=admission.exp.machinequota.cluster.x-k8s.io,sideEffects=None
+
+// Validator denies the creation of Machines, and the creation or scale up of MachineSets and MachineDeployments,
+// including through their scale subresource, that would exceed the limits of a MachineQuota in the same namespace.
+// Machines created by a controller, e.g. a MachineSet or a control plane provider, are not denied
+// so that rollouts and remediation can replace existing Machines; their number is limited through replicas instead,
+// which are checked against both MaxMachines and MaxReplicasPerCluster.
+// All requests are allowed if the MachineQuota feature is disabled.
+type Validator struct {
+	// APIReader is used to compute the usage of MachineQuotas. It should read from the API server rather than
+	// from a cache, e.g. mgr.GetAPIReader(), so that Machines created in quick succession are all accounted for.
+	APIReader client.Reader
+
+	decoder *admission.Decoder
+}
+
+var _ admission.Handler = &Validator{}
+var _ admission.DecoderInjector = &Validator{}
+
+// SetupWebhookWithManager registers the Validator with the manager's webhook server.
+func (v *Validator) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	mgr.GetWebhookServer().Register(webhookPath, &webhook.Admission{Handler: v})
+	return nil
+}
+
+// InjectDecoder implements admission.DecoderInjector.
+func (v *Validator) InjectDecoder(d *admission.Decoder) error {
+	v.decoder = d
+	return nil
+}
+
+// request is the usage a single admission request adds for a Cluster.
+type request struct {
+	clusterName string
+	machines    int32
+	replicas    int32
+}
+
+// Handle implements admission.Handler.
+func (v *Validator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	if !feature.Gates.Enabled(feature.MachineQuota) {
+		return admission.Allowed("")
+	}
+
+	r, err := v.requestFor(ctx, req)
+	if err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	reason, err := check(ctx, v.APIReader, req.Namespace, r)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	if reason != "" {
+		return admission.Denied(reason)
+	}
+	return admission.Allowed("")
+}
+
+// CheckReplicas returns a message explaining why adding replicas to the Cluster with the given name would exceed
+// the limits of a MachineQuota, or an empty string if it's within the limits of all the MachineQuotas applying to the Cluster.
+// It's meant for control plane providers to limit the replicas of their control planes, which are counted in the usage
+// of MachineQuotas but can't be validated by the Validator.
+// All replicas are allowed if the MachineQuota feature is disabled.
+func CheckReplicas(ctx context.Context, c client.Reader, namespace, clusterName string, replicas int32) (string, error) {
+	if !feature.Gates.Enabled(feature.MachineQuota) {
+		return "", nil
+	}
+	return check(ctx, c, namespace, request{clusterName: clusterName, machines: replicas, replicas: replicas})
+}
+
+// check returns a message describing the MachineQuota r exceeds, or an empty string if r is within
+// the limits of all the MachineQuotas in namespace.
+func check(ctx context.Context, c client.Reader, namespace string, r request) (string, error) {
+	if r.machines <= 0 && r.replicas <= 0 {
+		return "", nil
+	}
+
+	quotas := &expv1.MachineQuotaList{}
+	if err := c.List(ctx, quotas, client.InNamespace(namespace)); err != nil {
+		return "", err
+	}
+
+	for i := range quotas.Items {
+		quota := &quotas.Items[i]
+		if !quota.AppliesTo(r.clusterName) {
+			continue
+		}
+
+		usage, err := ComputeUsage(ctx, c, quota)
+		if err != nil {
+			return "", err
+		}
+
+		if reason := exceeds(quota, usage, r); reason != "" {
+			return reason, nil
+		}
+	}
+
+	return "", nil
+}
+
+// requestFor decodes the object in req and returns the usage it adds.
+// Scaling up a MachineSet or a MachineDeployment adds as many Machines as replicas.
+func (v *Validator) requestFor(ctx context.Context, req admission.Request) (request, error) {
+	if req.SubResource == "scale" {
+		return v.scaleRequestFor(ctx, req)
+	}
+
+	switch req.Kind.Kind {
+	case "Machine":
+		if req.Operation != admissionv1beta1.Create {
+			return request{}, nil
+		}
+		m := &clusterv1.Machine{}
+		if err := v.decoder.Decode(req, m); err != nil {
+			return request{}, err
+		}
+		if metav1.GetControllerOf(m) != nil {
+			return request{}, nil
+		}
+		return request{clusterName: m.Spec.ClusterName, machines: 1}, nil
+
+	case "MachineSet":
+		ms := &clusterv1.MachineSet{}
+		if err := v.decoder.Decode(req, ms); err != nil {
+			return request{}, err
+		}
+		// The replicas of a MachineSet managed by a MachineDeployment are accounted for by the MachineDeployment.
+		if isManagedByMachineDeployment(ms) {
+			return request{}, nil
+		}
+		var oldReplicas int32
+		if req.Operation == admissionv1beta1.Update {
+			old := &clusterv1.MachineSet{}
+			if err := v.decoder.DecodeRaw(req.OldObject, old); err != nil {
+				return request{}, err
+			}
+			oldReplicas = replicas(old.Spec.Replicas)
+		}
+		return newReplicasRequest(ms.Spec.ClusterName, replicas(ms.Spec.Replicas)-oldReplicas), nil
+
+	case "MachineDeployment":
+		md := &clusterv1.MachineDeployment{}
+		if err := v.decoder.Decode(req, md); err != nil {
+			return request{}, err
+		}
+		var oldReplicas int32
+		if req.Operation == admissionv1beta1.Update {
+			old := &clusterv1.MachineDeployment{}
+			if err := v.decoder.DecodeRaw(req.OldObject, old); err != nil {
+				return request{}, err
+			}
+			oldReplicas = replicas(old.Spec.Replicas)
+		}
+		return newReplicasRequest(md.Spec.ClusterName, replicas(md.Spec.Replicas)-oldReplicas), nil
+	}
+
+	return request{}, nil
+}
+
+// scaleRequestFor decodes the Scale in req, sent for the scale subresource of a MachineSet or a MachineDeployment,
+// and returns the usage it adds. The Cluster is read from the scaled object, which isn't part of the request.
+func (v *Validator) scaleRequestFor(ctx context.Context, req admission.Request) (request, error) {
+	if req.Operation != admissionv1beta1.Update {
+		return request{}, nil
+	}
+
+	scale := &autoscalingv1.Scale{}
+	if err := v.decoder.Decode(req, scale); err != nil {
+		return request{}, err
+	}
+	old := &autoscalingv1.Scale{}
+	if err := v.decoder.DecodeRaw(req.OldObject, old); err != nil {
+		return request{}, err
+	}
+	if scale.Spec.Replicas <= old.Spec.Replicas {
+		return request{}, nil
+	}
+
+	key := client.ObjectKey{Namespace: req.Namespace, Name: req.Name}
+	var clusterName string
+	switch req.Resource.Resource {
+	case "machinesets":
+		ms := &clusterv1.MachineSet{}
+		if err := v.APIReader.Get(ctx, key, ms); err != nil {
+			return request{}, err
+		}
+		if isManagedByMachineDeployment(ms) {
+			return request{}, nil
+		}
+		clusterName = ms.Spec.ClusterName
+	case "machinedeployments":
+		md := &clusterv1.MachineDeployment{}
+		if err := v.APIReader.Get(ctx, key, md); err != nil {
+			return request{}, err
+		}
+		clusterName = md.Spec.ClusterName
+	default:
+		return request{}, nil
+	}
+
+	return newReplicasRequest(clusterName, scale.Spec.Replicas-old.Spec.Replicas), nil
+}
+
+// newReplicasRequest returns the usage added by requesting replicas more replicas for a Cluster,
+// each of them adding a Machine.
+func newReplicasRequest(clusterName string, replicas int32) request {
+	return request{clusterName: clusterName, machines: replicas, replicas: replicas}
+}
+
+// exceeds returns a message describing the limit of quota r exceeds given the current usage,
+// or an empty string if r is within the limits of quota.
+func exceeds(quota *expv1.MachineQuota, usage *Usage, r request) string {
+	if r.machines > 0 && quota.Spec.MaxMachines != nil && usage.Machines+r.machines > *quota.Spec.MaxMachines {
+		return fmt.Sprintf("exceeded MachineQuota %q: requested %d Machine(s), used %d, limited to %d",
+			quota.Name, r.machines, usage.Machines, *quota.Spec.MaxMachines)
+	}
+
+	used := usage.ClusterReplicas[r.clusterName]
+	if r.replicas > 0 && quota.Spec.MaxReplicasPerCluster != nil && used+r.replicas > *quota.Spec.MaxReplicasPerCluster {
+		return fmt.Sprintf("exceeded MachineQuota %q for Cluster %q: requested %d additional replica(s), used %d, limited to %d",
+			quota.Name, r.clusterName, r.replicas, used, *quota.Spec.MaxReplicasPerCluster)
+	}
+
+	return ""
+}
@@ -0,0 +1,288 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package quota
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/pointer"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	expv1 "sigs.k8s.io/cluster-api/exp/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/feature"
+	"sigs.k8s.io/cluster-api/util/admissiontest"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+func newScale(namespace, name string, replicas int32) *autoscalingv1.Scale {
+	return &autoscalingv1.Scale{
+		TypeMeta:   metav1.TypeMeta{APIVersion: autoscalingv1.SchemeGroupVersion.String(), Kind: "Scale"},
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+		Spec:       autoscalingv1.ScaleSpec{Replicas: replicas},
+	}
+}
+
+func TestValidatorHandle(t *testing.T) {
+	managedMachineSet := newMachineSet("default", "managed", "cluster-1", 10)
+	managedMachineSet.OwnerReferences = []metav1.OwnerReference{
+		{APIVersion: clusterv1.GroupVersion.String(), Kind: "MachineDeployment", Name: "md-1"},
+	}
+
+	ownedMachine := newMachine("default", "m-3", "cluster-1")
+	ownedMachine.OwnerReferences = []metav1.OwnerReference{
+		{APIVersion: clusterv1.GroupVersion.String(), Kind: "MachineSet", Name: "ms-1", Controller: pointer.BoolPtr(true)},
+	}
+
+	tests := []struct {
+		name        string
+		operation   admissionv1beta1.Operation
+		obj         runtime.Object
+		old         runtime.Object
+		scaled      string
+		maxMachines int32
+		allowed     bool
+	}{
+		{
+			name:        "should deny creating a Machine exceeding maxMachines",
+			operation:   admissionv1beta1.Create,
+			obj:         newMachine("default", "m-3", "cluster-1"),
+			maxMachines: 2,
+			allowed:     false,
+		},
+		{
+			name:        "should allow creating a Machine for a Cluster the quota doesn't apply to",
+			operation:   admissionv1beta1.Create,
+			obj:         newMachine("default", "m-3", "cluster-2"),
+			maxMachines: 2,
+			allowed:     true,
+		},
+		{
+			name:        "should allow creating a Machine owned by a controller exceeding maxMachines",
+			operation:   admissionv1beta1.Create,
+			obj:         ownedMachine,
+			maxMachines: 2,
+			allowed:     true,
+		},
+		{
+			name:        "should allow updating a Machine",
+			operation:   admissionv1beta1.Update,
+			obj:         newMachine("default", "m-1", "cluster-1"),
+			old:         newMachine("default", "m-1", "cluster-1"),
+			maxMachines: 2,
+			allowed:     true,
+		},
+		{
+			name:        "should allow creating a MachineDeployment within maxReplicasPerCluster",
+			operation:   admissionv1beta1.Create,
+			obj:         newMachineDeployment("default", "md-2", "cluster-1", 2),
+			maxMachines: 10,
+			allowed:     true,
+		},
+		{
+			name:        "should deny creating a MachineDeployment exceeding maxReplicasPerCluster",
+			operation:   admissionv1beta1.Create,
+			obj:         newMachineDeployment("default", "md-2", "cluster-1", 3),
+			maxMachines: 10,
+			allowed:     false,
+		},
+		{
+			name:        "should deny scaling up a MachineDeployment exceeding maxReplicasPerCluster",
+			operation:   admissionv1beta1.Update,
+			obj:         newMachineDeployment("default", "md-1", "cluster-1", 6),
+			old:         newMachineDeployment("default", "md-1", "cluster-1", 3),
+			maxMachines: 10,
+			allowed:     false,
+		},
+		{
+			name:        "should allow scaling down a MachineDeployment",
+			operation:   admissionv1beta1.Update,
+			obj:         newMachineDeployment("default", "md-1", "cluster-1", 1),
+			old:         newMachineDeployment("default", "md-1", "cluster-1", 3),
+			maxMachines: 2,
+			allowed:     true,
+		},
+		{
+			name:        "should deny creating a MachineSet exceeding maxReplicasPerCluster",
+			operation:   admissionv1beta1.Create,
+			obj:         newMachineSet("default", "ms-1", "cluster-1", 3),
+			maxMachines: 10,
+			allowed:     false,
+		},
+		{
+			name:        "should allow creating a MachineSet managed by a MachineDeployment",
+			operation:   admissionv1beta1.Create,
+			obj:         managedMachineSet,
+			maxMachines: 2,
+			allowed:     true,
+		},
+		{
+			name:        "should deny creating a MachineDeployment exceeding maxMachines",
+			operation:   admissionv1beta1.Create,
+			obj:         newMachineDeployment("default", "md-2", "cluster-1", 2),
+			maxMachines: 3,
+			allowed:     false,
+		},
+		{
+			name:        "should deny scaling up a MachineSet exceeding maxMachines",
+			operation:   admissionv1beta1.Update,
+			obj:         newMachineSet("default", "ms-1", "cluster-1", 1),
+			old:         newMachineSet("default", "ms-1", "cluster-1", 0),
+			maxMachines: 2,
+			allowed:     false,
+		},
+		{
+			name:        "should allow scaling up a MachineDeployment through its scale subresource within the limits",
+			operation:   admissionv1beta1.Update,
+			obj:         newScale("default", "md-1", 4),
+			old:         newScale("default", "md-1", 3),
+			scaled:      "machinedeployments",
+			maxMachines: 10,
+			allowed:     true,
+		},
+		{
+			name:        "should deny scaling up a MachineDeployment through its scale subresource exceeding maxReplicasPerCluster",
+			operation:   admissionv1beta1.Update,
+			obj:         newScale("default", "md-1", 6),
+			old:         newScale("default", "md-1", 3),
+			scaled:      "machinedeployments",
+			maxMachines: 10,
+			allowed:     false,
+		},
+		{
+			name:        "should deny scaling up a MachineDeployment through its scale subresource exceeding maxMachines",
+			operation:   admissionv1beta1.Update,
+			obj:         newScale("default", "md-1", 4),
+			old:         newScale("default", "md-1", 3),
+			scaled:      "machinedeployments",
+			maxMachines: 2,
+			allowed:     false,
+		},
+		{
+			name:        "should allow scaling down a MachineDeployment through its scale subresource",
+			operation:   admissionv1beta1.Update,
+			obj:         newScale("default", "md-1", 1),
+			old:         newScale("default", "md-1", 3),
+			scaled:      "machinedeployments",
+			maxMachines: 2,
+			allowed:     true,
+		},
+		{
+			name:        "should deny scaling up a MachineSet through its scale subresource exceeding maxReplicasPerCluster",
+			operation:   admissionv1beta1.Update,
+			obj:         newScale("default", "ms-2", 3),
+			old:         newScale("default", "ms-2", 0),
+			scaled:      "machinesets",
+			maxMachines: 10,
+			allowed:     false,
+		},
+		{
+			name:        "should allow scaling up a MachineSet managed by a MachineDeployment through its scale subresource",
+			operation:   admissionv1beta1.Update,
+			obj:         newScale("default", "managed", 20),
+			old:         newScale("default", "managed", 10),
+			scaled:      "machinesets",
+			maxMachines: 2,
+			allowed:     true,
+		},
+	}
+
+	defer func() {
+		_ = feature.MutableGates.Set("MachineQuota=false")
+	}()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			scheme := newScheme(g)
+
+			quota := &expv1.MachineQuota{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "quota"},
+				Spec: expv1.MachineQuotaSpec{
+					ClusterNames:          []string{"cluster-1"},
+					MaxMachines:           pointer.Int32Ptr(tt.maxMachines),
+					MaxReplicasPerCluster: pointer.Int32Ptr(5),
+				},
+			}
+			c := fake.NewFakeClientWithScheme(scheme,
+				quota,
+				newMachine("default", "m-1", "cluster-1"),
+				newMachine("default", "m-2", "cluster-1"),
+				newMachineDeployment("default", "md-1", "cluster-1", 3),
+				newMachineSet("default", "ms-2", "cluster-1", 0),
+				managedMachineSet,
+			)
+
+			decoder, err := admission.NewDecoder(scheme)
+			g.Expect(err).NotTo(HaveOccurred())
+			v := &Validator{APIReader: c}
+			g.Expect(v.InjectDecoder(decoder)).To(Succeed())
+
+			req, err := admissiontest.NewRequest(tt.operation, "default", tt.obj, tt.old)
+			g.Expect(err).NotTo(HaveOccurred())
+			if tt.scaled != "" {
+				req.Name = tt.obj.(metav1.Object).GetName()
+				req.Resource = metav1.GroupVersionResource{Group: clusterv1.GroupVersion.Group, Version: clusterv1.GroupVersion.Version, Resource: tt.scaled}
+				req.SubResource = "scale"
+			}
+
+			g.Expect(feature.MutableGates.Set("MachineQuota=false")).To(Succeed())
+			g.Expect(v.Handle(context.Background(), req).Allowed).To(BeTrue())
+
+			g.Expect(feature.MutableGates.Set("MachineQuota=true")).To(Succeed())
+			g.Expect(v.Handle(context.Background(), req).Allowed).To(Equal(tt.allowed))
+		})
+	}
+}
+
+func TestCheckReplicas(t *testing.T) {
+	g := NewWithT(t)
+
+	quota := &expv1.MachineQuota{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "quota"},
+		Spec: expv1.MachineQuotaSpec{
+			ClusterNames:          []string{"cluster-1"},
+			MaxMachines:           pointer.Int32Ptr(4),
+			MaxReplicasPerCluster: pointer.Int32Ptr(5),
+		},
+	}
+	c := fake.NewFakeClientWithScheme(newScheme(g),
+		quota,
+		newMachine("default", "m-1", "cluster-1"),
+		newMachine("default", "m-2", "cluster-1"),
+		newMachineDeployment("default", "md-1", "cluster-1", 3),
+	)
+
+	defer func() {
+		_ = feature.MutableGates.Set("MachineQuota=false")
+	}()
+
+	g.Expect(feature.MutableGates.Set("MachineQuota=false")).To(Succeed())
+	g.Expect(CheckReplicas(context.Background(), c, "default", "cluster-1", 10)).To(BeEmpty())
+
+	g.Expect(feature.MutableGates.Set("MachineQuota=true")).To(Succeed())
+	g.Expect(CheckReplicas(context.Background(), c, "default", "cluster-1", 1)).To(BeEmpty())
+	g.Expect(CheckReplicas(context.Background(), c, "default", "cluster-1", 3)).To(ContainSubstring("requested 3 Machine(s)"))
+	g.Expect(CheckReplicas(context.Background(), c, "default", "cluster-1", -1)).To(BeEmpty())
+	g.Expect(CheckReplicas(context.Background(), c, "default", "cluster-2", 10)).To(BeEmpty())
+}
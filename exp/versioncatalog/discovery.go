@@ -0,0 +1,136 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package versioncatalog
+
+import (
+	"context"
+	"sort"
+
+	"github.com/blang/semver"
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
+	"sigs.k8s.io/cluster-api/controllers/external"
+	expv1 "sigs.k8s.io/cluster-api/exp/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/util"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Discover returns the Kubernetes versions reported in `status.kubernetesVersions` by each source of catalog.
+// Sources that can't be read are left out of the result and reported in the returned error.
+func Discover(ctx context.Context, c client.Client, catalog *expv1.KubernetesVersionCatalog) ([]expv1.KubernetesVersionSource, error) {
+	sources := []expv1.KubernetesVersionSource{}
+	errs := []error{}
+	for i := range catalog.Spec.Sources {
+		ref := catalog.Spec.Sources[i]
+
+		namespace := ref.Namespace
+		if namespace == "" {
+			namespace = catalog.Namespace
+		}
+
+		obj, err := external.Get(ctx, c, &ref, namespace)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		versions, found, err := unstructured.NestedStringSlice(obj.Object, "status", "kubernetesVersions")
+		if err != nil {
+			errs = append(errs, errors.Wrapf(err, "failed to read Kubernetes versions from %v %q in namespace %q",
+				obj.GroupVersionKind(), obj.GetName(), namespace))
+			continue
+		}
+		if !found {
+			errs = append(errs, errors.Errorf("%v %q in namespace %q doesn't report any Kubernetes versions",
+				obj.GroupVersionKind(), obj.GetName(), namespace))
+			continue
+		}
+
+		sources = append(sources, expv1.KubernetesVersionSource{
+			Ref:      ref,
+			Versions: versions,
+		})
+	}
+	return sources, kerrors.NewAggregate(errs)
+}
+
+// Available returns the Kubernetes versions offered by every one of the sources, sorted in ascending order.
+// Versions are compared on major.minor.patch only; versions that can't be parsed are ignored.
+func Available(sources []expv1.KubernetesVersionSource) []string {
+	if len(sources) == 0 {
+		return nil
+	}
+
+	// Count the number of sources offering each version, keeping the first spelling encountered.
+	spelling := map[string]string{}
+	count := map[string]int{}
+	parsed := map[string]semver.Version{}
+	for _, source := range sources {
+		seen := map[string]bool{}
+		for _, v := range source.Versions {
+			sv, err := util.ParseMajorMinorPatch(v)
+			if err != nil {
+				continue
+			}
+			key := sv.String()
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			if _, ok := spelling[key]; !ok {
+				spelling[key] = v
+				parsed[key] = sv
+			}
+			count[key]++
+		}
+	}
+
+	keys := []string{}
+	for key, n := range count {
+		if n == len(sources) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return parsed[keys[i]].LT(parsed[keys[j]])
+	})
+
+	versions := make([]string, 0, len(keys))
+	for _, key := range keys {
+		versions = append(versions, spelling[key])
+	}
+	return versions
+}
+
+// Contains returns true if version is one of versions, comparing major.minor.patch only.
+func Contains(versions []string, version string) bool {
+	want, err := util.ParseMajorMinorPatch(version)
+	if err != nil {
+		return false
+	}
+	for _, v := range versions {
+		sv, err := util.ParseMajorMinorPatch(v)
+		if err != nil {
+			continue
+		}
+		if sv.EQ(want) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,132 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package versioncatalog
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	expv1 "sigs.k8s.io/cluster-api/exp/api/v1alpha3"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func newSource(name string, versions ...interface{}) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{}
+	u.SetAPIVersion("infrastructure.cluster.x-k8s.io/v1alpha3")
+	u.SetKind("GenericMachineImageCatalog")
+	u.SetNamespace("default")
+	u.SetName(name)
+	if versions != nil {
+		_ = unstructured.SetNestedSlice(u.Object, versions, "status", "kubernetesVersions")
+	}
+	return u
+}
+
+func sourceRef(name string) corev1.ObjectReference {
+	return corev1.ObjectReference{
+		APIVersion: "infrastructure.cluster.x-k8s.io/v1alpha3",
+		Kind:       "GenericMachineImageCatalog",
+		Name:       name,
+	}
+}
+
+func TestDiscover(t *testing.T) {
+	g := NewWithT(t)
+
+	c := fake.NewFakeClientWithScheme(runtime.NewScheme(),
+		newSource("images-1", "v1.17.5", "v1.18.2"),
+		newSource("images-2"),
+	)
+
+	catalog := &expv1.KubernetesVersionCatalog{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "catalog"},
+		Spec: expv1.KubernetesVersionCatalogSpec{
+			Sources: []corev1.ObjectReference{sourceRef("images-1")},
+		},
+	}
+
+	sources, err := Discover(context.Background(), c, catalog)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(sources).To(Equal([]expv1.KubernetesVersionSource{
+		{Ref: sourceRef("images-1"), Versions: []string{"v1.17.5", "v1.18.2"}},
+	}))
+
+	// Sources not reporting any version, or not found, are reported as errors.
+	catalog.Spec.Sources = append(catalog.Spec.Sources, sourceRef("images-2"), sourceRef("images-3"))
+	sources, err = Discover(context.Background(), c, catalog)
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(sources).To(HaveLen(1))
+}
+
+func TestAvailable(t *testing.T) {
+	tests := []struct {
+		name     string
+		sources  []expv1.KubernetesVersionSource
+		expected []string
+	}{
+		{
+			name:     "no sources",
+			expected: nil,
+		},
+		{
+			name: "single source, sorted by version",
+			sources: []expv1.KubernetesVersionSource{
+				{Versions: []string{"v1.18.2", "v1.17.10", "v1.17.5"}},
+			},
+			expected: []string{"v1.17.5", "v1.17.10", "v1.18.2"},
+		},
+		{
+			name: "versions offered by all sources",
+			sources: []expv1.KubernetesVersionSource{
+				{Versions: []string{"v1.17.5", "v1.18.2", "v1.18.3"}},
+				{Versions: []string{"1.18.2", "v1.18.3+build.1", "v1.19.0"}},
+			},
+			expected: []string{"v1.18.2", "v1.18.3"},
+		},
+		{
+			name: "invalid versions are ignored",
+			sources: []expv1.KubernetesVersionSource{
+				{Versions: []string{"latest", "v1.18.2"}},
+			},
+			expected: []string{"v1.18.2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			g.Expect(Available(tt.sources)).To(Equal(tt.expected))
+		})
+	}
+}
+
+func TestContains(t *testing.T) {
+	g := NewWithT(t)
+
+	versions := []string{"v1.17.5", "v1.18.2"}
+	g.Expect(Contains(versions, "v1.18.2")).To(BeTrue())
+	g.Expect(Contains(versions, "1.17.5")).To(BeTrue())
+	g.Expect(Contains(versions, "v1.18.3")).To(BeFalse())
+	g.Expect(Contains(versions, "invalid")).To(BeFalse())
+	g.Expect(Contains(nil, "v1.18.2")).To(BeFalse())
+}
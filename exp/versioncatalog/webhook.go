@@ -0,0 +1,171 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package versioncatalog
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	expv1 "sigs.k8s.io/cluster-api/exp/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/feature"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+const webhookPath = "/validate-exp-cluster-x-k8s-io-v1alpha3-kubernetesversioncatalog-admission"
+
+// +kubebuilder:webhook:verbs=create;update,path=/validate-exp-cluster-x-k8s-io-v1alpha3-kubernetesversioncatalog-admission,mutating=false,failurePolicy=fail,matchPolicy=Equivalent,groups=cluster.x-k8s.io,resources=machines;machinesets;machinedeployments,versions=v1alpha3,name=admission.exp.kubernetesversioncatalog.cluster.x-k8s.io,sideEffects=None
+
+// Validator checks the Kubernetes version of Machines, MachineSets and MachineDeployments against
+// the KubernetesVersionCatalogs of their namespace, see CheckVersion.
+// Control plane providers are expected to validate the versions of their own types.
+type Validator struct {
+	// APIReader is used to read KubernetesVersionCatalogs, without requiring the manager to cache them.
+	APIReader client.Reader
+
+	decoder *admission.Decoder
+}
+
+var _ admission.Handler = &Validator{}
+var _ admission.DecoderInjector = &Validator{}
+
+// SetupWebhookWithManager registers the Validator with the manager's webhook server.
+func (v *Validator) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	mgr.GetWebhookServer().Register(webhookPath, &webhook.Admission{Handler: v})
+	return nil
+}
+
+// InjectDecoder implements admission.DecoderInjector.
+func (v *Validator) InjectDecoder(d *admission.Decoder) error {
+	v.decoder = d
+	return nil
+}
+
+// Handle implements admission.Handler.
+func (v *Validator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	var obj, old runtime.Object
+	switch req.Kind.Kind {
+	case "Machine":
+		obj, old = &clusterv1.Machine{}, &clusterv1.Machine{}
+	case "MachineSet":
+		obj, old = &clusterv1.MachineSet{}, &clusterv1.MachineSet{}
+	case "MachineDeployment":
+		obj, old = &clusterv1.MachineDeployment{}, &clusterv1.MachineDeployment{}
+	default:
+		return admission.Allowed("")
+	}
+
+	if err := v.decoder.Decode(req, obj); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	clusterName, version := versionOf(obj)
+	if version == "" {
+		return admission.Allowed("")
+	}
+	if req.Operation == admissionv1beta1.Update {
+		if err := v.decoder.DecodeRaw(req.OldObject, old); err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+		if _, oldVersion := versionOf(old); oldVersion == version {
+			return admission.Allowed("")
+		}
+	}
+
+	reason, err := CheckVersion(ctx, v.APIReader, req.Namespace, clusterName, version)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	if reason != "" {
+		return admission.Denied(reason)
+	}
+	return admission.Allowed("")
+}
+
+// versionOf returns the Cluster obj belongs to and the Kubernetes version it sets.
+// Machines and MachineSets controlled by another object don't set a version, it's inherited from their controller.
+func versionOf(obj runtime.Object) (string, string) {
+	switch o := obj.(type) {
+	case *clusterv1.Machine:
+		if metav1.GetControllerOf(o) != nil {
+			return "", ""
+		}
+		return o.Spec.ClusterName, stringValue(o.Spec.Version)
+	case *clusterv1.MachineSet:
+		if metav1.GetControllerOf(o) != nil {
+			return "", ""
+		}
+		return o.Spec.ClusterName, stringValue(o.Spec.Template.Spec.Version)
+	case *clusterv1.MachineDeployment:
+		return o.Spec.ClusterName, stringValue(o.Spec.Template.Spec.Version)
+	}
+	return "", ""
+}
+
+// CheckVersion returns a message explaining why the Kubernetes version can't be set for the Cluster
+// with the given name, or an empty string if it's available from all the KubernetesVersionCatalogs
+// applying to the Cluster.
+// A catalog that isn't ready, because it hasn't discovered the versions of all its sources yet or failed to,
+// denies all the versions: a version available from the sources it discovered may not be available from the others.
+// All versions are allowed if the KubernetesVersionCatalog feature is disabled.
+func CheckVersion(ctx context.Context, c client.Reader, namespace, clusterName, version string) (string, error) {
+	if !feature.Gates.Enabled(feature.KubernetesVersionCatalog) {
+		return "", nil
+	}
+
+	catalogs := &expv1.KubernetesVersionCatalogList{}
+	if err := c.List(ctx, catalogs, client.InNamespace(namespace)); err != nil {
+		return "", err
+	}
+
+	for i := range catalogs.Items {
+		catalog := &catalogs.Items[i]
+		if !catalog.AppliesTo(clusterName) {
+			continue
+		}
+
+		if !catalog.Status.Ready {
+			reason := "versions have not been discovered from all the sources yet"
+			if catalog.Status.FailureMessage != nil {
+				reason = *catalog.Status.FailureMessage
+			}
+			return fmt.Sprintf("cannot check Kubernetes version %q against KubernetesVersionCatalog %q: %s",
+				version, catalog.Name, reason), nil
+		}
+
+		if !Contains(catalog.Status.Versions, version) {
+			return fmt.Sprintf("Kubernetes version %q is not available from KubernetesVersionCatalog %q, available versions are [%s]",
+				version, catalog.Name, strings.Join(catalog.Status.Versions, ", ")), nil
+		}
+	}
+
+	return "", nil
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,243 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package versioncatalog
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/pointer"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	expv1 "sigs.k8s.io/cluster-api/exp/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/feature"
+	"sigs.k8s.io/cluster-api/util/admissiontest"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+func newMachineDeployment(clusterName, version string) *clusterv1.MachineDeployment {
+	return &clusterv1.MachineDeployment{
+		TypeMeta:   metav1.TypeMeta{APIVersion: clusterv1.GroupVersion.String(), Kind: "MachineDeployment"},
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "md"},
+		Spec: clusterv1.MachineDeploymentSpec{
+			ClusterName: clusterName,
+			Template: clusterv1.MachineTemplateSpec{
+				Spec: clusterv1.MachineSpec{ClusterName: clusterName, Version: pointer.StringPtr(version)},
+			},
+		},
+	}
+}
+
+func newMachine(clusterName, version string, controlled bool) *clusterv1.Machine {
+	m := &clusterv1.Machine{
+		TypeMeta:   metav1.TypeMeta{APIVersion: clusterv1.GroupVersion.String(), Kind: "Machine"},
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "machine"},
+		Spec:       clusterv1.MachineSpec{ClusterName: clusterName, Version: pointer.StringPtr(version)},
+	}
+	if controlled {
+		m.OwnerReferences = []metav1.OwnerReference{
+			{APIVersion: clusterv1.GroupVersion.String(), Kind: "MachineSet", Name: "ms", Controller: pointer.BoolPtr(true)},
+		}
+	}
+	return m
+}
+
+func TestValidatorHandle(t *testing.T) {
+	tests := []struct {
+		name      string
+		operation admissionv1beta1.Operation
+		obj       runtime.Object
+		old       runtime.Object
+		allowed   bool
+	}{
+		{
+			name:      "should allow creating a MachineDeployment with an available version",
+			operation: admissionv1beta1.Create,
+			obj:       newMachineDeployment("cluster-1", "v1.18.2"),
+			allowed:   true,
+		},
+		{
+			name:      "should deny creating a MachineDeployment with a version that isn't available",
+			operation: admissionv1beta1.Create,
+			obj:       newMachineDeployment("cluster-1", "v1.19.0"),
+			allowed:   false,
+		},
+		{
+			name:      "should allow creating a MachineDeployment for a Cluster the catalog doesn't apply to",
+			operation: admissionv1beta1.Create,
+			obj:       newMachineDeployment("cluster-2", "v1.19.0"),
+			allowed:   true,
+		},
+		{
+			name:      "should deny upgrading a MachineDeployment to a version that isn't available",
+			operation: admissionv1beta1.Update,
+			obj:       newMachineDeployment("cluster-1", "v1.19.0"),
+			old:       newMachineDeployment("cluster-1", "v1.18.2"),
+			allowed:   false,
+		},
+		{
+			name:      "should allow updating a MachineDeployment without changing its version",
+			operation: admissionv1beta1.Update,
+			obj:       newMachineDeployment("cluster-1", "v1.16.0"),
+			old:       newMachineDeployment("cluster-1", "v1.16.0"),
+			allowed:   true,
+		},
+		{
+			name:      "should deny creating a Machine with a version that isn't available",
+			operation: admissionv1beta1.Create,
+			obj:       newMachine("cluster-1", "v1.19.0", false),
+			allowed:   false,
+		},
+		{
+			name:      "should allow creating a Machine controlled by a MachineSet",
+			operation: admissionv1beta1.Create,
+			obj:       newMachine("cluster-1", "v1.19.0", true),
+			allowed:   true,
+		},
+	}
+
+	defer func() {
+		_ = feature.MutableGates.Set("KubernetesVersionCatalog=false")
+	}()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			scheme := runtime.NewScheme()
+			g.Expect(clusterv1.AddToScheme(scheme)).To(Succeed())
+			g.Expect(expv1.AddToScheme(scheme)).To(Succeed())
+
+			catalog := &expv1.KubernetesVersionCatalog{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "catalog"},
+				Spec: expv1.KubernetesVersionCatalogSpec{
+					ClusterNames: []string{"cluster-1"},
+				},
+				Status: expv1.KubernetesVersionCatalogStatus{
+					Ready:    true,
+					Versions: []string{"v1.17.5", "v1.18.2"},
+				},
+			}
+			c := fake.NewFakeClientWithScheme(scheme, catalog)
+
+			decoder, err := admission.NewDecoder(scheme)
+			g.Expect(err).NotTo(HaveOccurred())
+			v := &Validator{APIReader: c}
+			g.Expect(v.InjectDecoder(decoder)).To(Succeed())
+
+			req, err := admissiontest.NewRequest(tt.operation, "default", tt.obj, tt.old)
+			g.Expect(err).NotTo(HaveOccurred())
+
+			g.Expect(feature.MutableGates.Set("KubernetesVersionCatalog=false")).To(Succeed())
+			g.Expect(v.Handle(context.Background(), req).Allowed).To(BeTrue())
+
+			g.Expect(feature.MutableGates.Set("KubernetesVersionCatalog=true")).To(Succeed())
+			g.Expect(v.Handle(context.Background(), req).Allowed).To(Equal(tt.allowed))
+		})
+	}
+}
+
+func TestCheckVersion(t *testing.T) {
+	failure := "InfrastructureImageCatalog \"broken\" not found"
+
+	tests := []struct {
+		name    string
+		status  expv1.KubernetesVersionCatalogStatus
+		version string
+		allowed bool
+	}{
+		{
+			name:    "should allow a version available from a ready catalog",
+			status:  expv1.KubernetesVersionCatalogStatus{Ready: true, Versions: []string{"v1.17.5", "v1.18.2"}},
+			version: "v1.18.2",
+			allowed: true,
+		},
+		{
+			name:    "should deny a version not available from a ready catalog",
+			status:  expv1.KubernetesVersionCatalogStatus{Ready: true, Versions: []string{"v1.17.5", "v1.18.2"}},
+			version: "v1.19.0",
+			allowed: false,
+		},
+		{
+			name: "should deny a version available from the sources discovered by a catalog failing to read a source",
+			status: expv1.KubernetesVersionCatalogStatus{
+				Versions:       []string{"v1.18.2"},
+				Sources:        []expv1.KubernetesVersionSource{{Versions: []string{"v1.18.2"}}},
+				FailureMessage: &failure,
+			},
+			version: "v1.18.2",
+			allowed: false,
+		},
+		{
+			name: "should deny a version not available from the sources discovered by a catalog failing to read a source",
+			status: expv1.KubernetesVersionCatalogStatus{
+				Versions:       []string{"v1.18.2"},
+				Sources:        []expv1.KubernetesVersionSource{{Versions: []string{"v1.18.2"}}},
+				FailureMessage: &failure,
+			},
+			version: "v1.19.0",
+			allowed: false,
+		},
+		{
+			name:    "should deny all versions if a catalog failed to read all of its sources",
+			status:  expv1.KubernetesVersionCatalogStatus{FailureMessage: &failure},
+			version: "v1.18.2",
+			allowed: false,
+		},
+		{
+			name:    "should deny all versions if a catalog hasn't discovered versions yet",
+			version: "v1.18.2",
+			allowed: false,
+		},
+	}
+
+	defer func() {
+		_ = feature.MutableGates.Set("KubernetesVersionCatalog=false")
+	}()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			scheme := runtime.NewScheme()
+			g.Expect(expv1.AddToScheme(scheme)).To(Succeed())
+
+			catalog := &expv1.KubernetesVersionCatalog{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "catalog"},
+				Status:     tt.status,
+			}
+			c := fake.NewFakeClientWithScheme(scheme, catalog)
+
+			g.Expect(feature.MutableGates.Set("KubernetesVersionCatalog=true")).To(Succeed())
+			reason, err := CheckVersion(context.Background(), c, "default", "cluster-1", tt.version)
+			g.Expect(err).NotTo(HaveOccurred())
+			if tt.allowed {
+				g.Expect(reason).To(BeEmpty())
+			} else {
+				g.Expect(reason).NotTo(BeEmpty())
+			}
+			if tt.status.FailureMessage != nil && !tt.allowed {
+				g.Expect(reason).To(ContainSubstring(failure))
+			}
+		})
+	}
+}
@@ -34,9 +34,13 @@ import (
 	clusterv1alpha3 "sigs.k8s.io/cluster-api/api/v1alpha3"
 	"sigs.k8s.io/cluster-api/cmd/version"
 	"sigs.k8s.io/cluster-api/controllers"
+	"sigs.k8s.io/cluster-api/controllers/remote"
 	expv1alpha3 "sigs.k8s.io/cluster-api/exp/api/v1alpha3"
 	expcontrollers "sigs.k8s.io/cluster-api/exp/controllers"
+	"sigs.k8s.io/cluster-api/exp/quota"
+	"sigs.k8s.io/cluster-api/exp/versioncatalog"
 	"sigs.k8s.io/cluster-api/feature"
+	"sigs.k8s.io/cluster-api/util/dryrun"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -63,7 +67,15 @@ var (
 	machineDeploymentConcurrency  int
 	machinePoolConcurrency        int
 	machineHealthCheckConcurrency int
+	machineQuotaConcurrency       int
+	versionCatalogConcurrency     int
+	versionCatalogRefreshPeriod   time.Duration
+	dryRun                        bool
+	verifyNodeAttestation         bool
 	syncPeriod                    time.Duration
+	clusterSyncPeriod             time.Duration
+	machineSyncPeriod             time.Duration
+	machineHealthCheckSyncPeriod  time.Duration
 	webhookPort                   int
 	healthAddr                    string
 )
@@ -120,9 +132,36 @@ func InitFlags(fs *pflag.FlagSet) {
 	fs.IntVar(&machineHealthCheckConcurrency, "machinehealthcheck-concurrency", 10,
 		"Number of machine health checks to process simultaneously")
 
+	fs.IntVar(&machineQuotaConcurrency, "machinequota-concurrency", 10,
+		"Number of machine quotas to process simultaneously")
+
+	fs.IntVar(&versionCatalogConcurrency, "kubernetesversioncatalog-concurrency", 1,
+		"Number of kubernetes version catalogs to process simultaneously")
+
+	fs.DurationVar(&versionCatalogRefreshPeriod, "kubernetesversioncatalog-refresh-period", expcontrollers.DefaultKubernetesVersionCatalogRefreshPeriod,
+		"The interval at which available Kubernetes versions are discovered again from the sources of kubernetes version catalogs (e.g. 15m)")
+
 	fs.DurationVar(&syncPeriod, "sync-period", 10*time.Minute,
 		"The minimum interval at which watched resources are reconciled (e.g. 15m)")
 
+	fs.DurationVar(&clusterSyncPeriod, "cluster-sync-period", 0,
+		"The interval at which clusters are reconciled in the absence of changes, overriding --sync-period for clusters (e.g. 30m)")
+
+	fs.DurationVar(&machineSyncPeriod, "machine-sync-period", 0,
+		"The interval at which machines are reconciled in the absence of changes, overriding --sync-period for machines (e.g. 30m)")
+
+	fs.DurationVar(&machineHealthCheckSyncPeriod, "machinehealthcheck-sync-period", 0,
+		"The interval at which machine health checks are reconciled in the absence of changes, overriding --sync-period for machine health checks (e.g. 1m)")
+
+	fs.BoolVar(&dryRun, "dry-run", false,
+		"Log the changes the Cluster, Machine, MachineSet, MachineDeployment and MachineHealthCheck controllers would make, without persisting them. "+
+			"Dry-run mode can also be enabled for a single cluster with the cluster.x-k8s.io/dry-run annotation. "+
+			"The KubeadmControlPlane controller has its own --dry-run flag.")
+
+	fs.BoolVar(&verifyNodeAttestation, "verify-node-attestation", false,
+		"Only set the NodeRef of a Machine once its infrastructure object has the cluster.x-k8s.io/attested-node-uid annotation set to the UID of the Node. "+
+			"Nodes failing verification are left joined to the workload cluster, without being cordoned.")
+
 	fs.IntVar(&webhookPort, "webhook-port", 0,
 		"Webhook Server port, disabled by default. When enabled, the manager will only work as webhook server, no reconcilers are installed.")
 
@@ -196,30 +235,63 @@ func setupReconcilers(mgr ctrl.Manager) {
 		return
 	}
 
+	// Set up a ClusterCacheTracker and ClusterCacheReconciler to provide to controllers
+	// requiring a connection to a remote cluster
+	tracker, err := remote.NewClusterCacheTracker(
+		ctrl.Log.WithName("remote").WithName("ClusterCacheTracker"),
+		mgr,
+	)
+	if err != nil {
+		setupLog.Error(err, "unable to create cluster cache tracker")
+		os.Exit(1)
+	}
+	if _, err := remote.NewClusterCacheReconciler(
+		ctrl.Log.WithName("remote").WithName("ClusterCacheReconciler"),
+		mgr,
+		concurrency(clusterConcurrency),
+		tracker,
+	); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "ClusterCacheReconciler")
+		os.Exit(1)
+	}
+
+	// Changes made by the core controllers aren't persisted in dry-run mode, for all clusters or for the annotated ones.
+	dryRunClient := dryrun.NewClient(mgr.GetClient(), dryRun, ctrl.Log.WithName("dry-run"))
+
 	if err := (&controllers.ClusterReconciler{
-		Client: mgr.GetClient(),
-		Log:    ctrl.Log.WithName("controllers").WithName("Cluster"),
+		Client:     dryRunClient,
+		Log:        ctrl.Log.WithName("controllers").WithName("Cluster"),
+		SyncPeriod: clusterSyncPeriod,
 	}).SetupWithManager(mgr, concurrency(clusterConcurrency)); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Cluster")
 		os.Exit(1)
 	}
+	var nodeVerifier controllers.NodeVerifier
+	if verifyNodeAttestation {
+		nodeVerifier = &controllers.AttestedNodeVerifier{Client: mgr.GetClient()}
+	}
 	if err := (&controllers.MachineReconciler{
-		Client: mgr.GetClient(),
-		Log:    ctrl.Log.WithName("controllers").WithName("Machine"),
+		Client:       dryRunClient,
+		Log:          ctrl.Log.WithName("controllers").WithName("Machine"),
+		SyncPeriod:   machineSyncPeriod,
+		DryRun:       dryRun,
+		NodeVerifier: nodeVerifier,
 	}).SetupWithManager(mgr, concurrency(machineConcurrency)); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Machine")
 		os.Exit(1)
 	}
 	if err := (&controllers.MachineSetReconciler{
-		Client: mgr.GetClient(),
+		Client: dryRunClient,
 		Log:    ctrl.Log.WithName("controllers").WithName("MachineSet"),
+		DryRun: dryRun,
 	}).SetupWithManager(mgr, concurrency(machineSetConcurrency)); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "MachineSet")
 		os.Exit(1)
 	}
 	if err := (&controllers.MachineDeploymentReconciler{
-		Client: mgr.GetClient(),
+		Client: dryRunClient,
 		Log:    ctrl.Log.WithName("controllers").WithName("MachineDeployment"),
+		DryRun: dryRun,
 	}).SetupWithManager(mgr, concurrency(machineDeploymentConcurrency)); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "MachineDeployment")
 		os.Exit(1)
@@ -234,9 +306,32 @@ func setupReconcilers(mgr ctrl.Manager) {
 			os.Exit(1)
 		}
 	}
+
+	if feature.Gates.Enabled(feature.MachineQuota) {
+		if err := (&expcontrollers.MachineQuotaReconciler{
+			Client: mgr.GetClient(),
+			Log:    ctrl.Log.WithName("controllers").WithName("MachineQuota"),
+		}).SetupWithManager(mgr, concurrency(machineQuotaConcurrency)); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "MachineQuota")
+			os.Exit(1)
+		}
+	}
+
+	if feature.Gates.Enabled(feature.KubernetesVersionCatalog) {
+		if err := (&expcontrollers.KubernetesVersionCatalogReconciler{
+			Client:        mgr.GetClient(),
+			Log:           ctrl.Log.WithName("controllers").WithName("KubernetesVersionCatalog"),
+			RefreshPeriod: versionCatalogRefreshPeriod,
+		}).SetupWithManager(mgr, concurrency(versionCatalogConcurrency)); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "KubernetesVersionCatalog")
+			os.Exit(1)
+		}
+	}
 	if err := (&controllers.MachineHealthCheckReconciler{
-		Client: mgr.GetClient(),
-		Log:    ctrl.Log.WithName("controllers").WithName("MachineHealthCheck"),
+		Client:     dryRunClient,
+		Log:        ctrl.Log.WithName("controllers").WithName("MachineHealthCheck"),
+		Tracker:    tracker,
+		SyncPeriod: machineHealthCheckSyncPeriod,
 	}).SetupWithManager(mgr, concurrency(machineHealthCheckConcurrency)); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "MachineHealthCheck")
 		os.Exit(1)
@@ -315,6 +410,23 @@ func setupWebhooks(mgr ctrl.Manager) {
 		setupLog.Error(err, "unable to create webhook", "webhook", "MachineHealthCheck")
 		os.Exit(1)
 	}
+
+	// The MachineQuota admission webhook is always served, as it is configured for core types;
+	// requests are allowed without further checks unless the MachineQuota feature is enabled.
+	if err := (&quota.Validator{
+		APIReader: mgr.GetAPIReader(),
+	}).SetupWebhookWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create webhook", "webhook", "MachineQuota")
+		os.Exit(1)
+	}
+
+	// Same as above, requests are allowed unless the KubernetesVersionCatalog feature is enabled.
+	if err := (&versioncatalog.Validator{
+		APIReader: mgr.GetAPIReader(),
+	}).SetupWebhookWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create webhook", "webhook", "KubernetesVersionCatalog")
+		os.Exit(1)
+	}
 }
 
 func concurrency(c int) controller.Options {
@@ -0,0 +1,56 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package admissiontest implements helpers to test admission webhooks.
+package admissiontest
+
+import (
+	"encoding/json"
+
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// NewRequest returns an admission request for operation on obj in namespace, as sent by the API server.
+// obj must have its TypeMeta set, the kind of the request is taken from it.
+// old is the object before the operation, only used for updates; it can be nil.
+func NewRequest(operation admissionv1beta1.Operation, namespace string, obj, old runtime.Object) (admission.Request, error) {
+	gvk := obj.GetObjectKind().GroupVersionKind()
+	req := admission.Request{
+		AdmissionRequest: admissionv1beta1.AdmissionRequest{
+			Kind:      metav1.GroupVersionKind{Group: gvk.Group, Version: gvk.Version, Kind: gvk.Kind},
+			Namespace: namespace,
+			Operation: operation,
+		},
+	}
+
+	raw, err := json.Marshal(obj)
+	if err != nil {
+		return admission.Request{}, err
+	}
+	req.Object = runtime.RawExtension{Raw: raw}
+
+	if old != nil {
+		raw, err := json.Marshal(old)
+		if err != nil {
+			return admission.Request{}, err
+		}
+		req.OldObject = runtime.RawExtension{Raw: raw}
+	}
+	return req, nil
+}
@@ -0,0 +1,288 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package dryrun implements a client that doesn't persist the changes made by controllers
+// when dry-run mode is enabled, logging them instead.
+package dryrun
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/util/annotations"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Client wraps a client.Client so that changes to objects are not persisted, either for all objects
+// or for the objects belonging to a Cluster with the `dry-run` annotation.
+// Changes not persisted are still sent to the API server using the DryRunAll option, which validates them,
+// and are logged so that operators can review the actions controllers would take.
+// Controllers run in dry-run mode are expected to use it as their Client: their own DryRun option only
+// covers what the Client can't, e.g. changes to workload clusters or waiting for objects that won't be created,
+// and applies the same behavior to the Clusters with the `dry-run` annotation.
+type Client struct {
+	client.Client
+
+	// All enables dry-run mode for all objects, regardless of the Cluster they belong to.
+	All bool
+
+	// Log is used to log the changes that are not persisted.
+	Log logr.Logger
+}
+
+var _ client.Client = &Client{}
+
+// NewClient returns a Client wrapping c.
+func NewClient(c client.Client, all bool, log logr.Logger) *Client {
+	return &Client{
+		Client: c,
+		All:    all,
+		Log:    log,
+	}
+}
+
+// Create implements client.Client.
+func (c *Client) Create(ctx context.Context, obj runtime.Object, opts ...client.CreateOption) error {
+	dryRun, err := c.enabledFor(ctx, obj)
+	if err != nil {
+		return err
+	}
+	if dryRun {
+		c.log("create", obj)
+		opts = append(opts, client.DryRunAll)
+	}
+	return c.Client.Create(ctx, obj, opts...)
+}
+
+// Update implements client.Client.
+func (c *Client) Update(ctx context.Context, obj runtime.Object, opts ...client.UpdateOption) error {
+	dryRun, err := c.enabledFor(ctx, obj)
+	if err != nil {
+		return err
+	}
+	if dryRun {
+		c.log("update", obj)
+		opts = append(opts, client.DryRunAll)
+	}
+	return c.Client.Update(ctx, obj, opts...)
+}
+
+// Patch implements client.Client.
+func (c *Client) Patch(ctx context.Context, obj runtime.Object, patch client.Patch, opts ...client.PatchOption) error {
+	dryRun, err := c.enabledFor(ctx, obj)
+	if err != nil {
+		return err
+	}
+	if dryRun {
+		c.logPatch("patch", obj, patch)
+		opts = append(opts, client.DryRunAll)
+	}
+	return c.Client.Patch(ctx, obj, patch, opts...)
+}
+
+// Delete implements client.Client.
+func (c *Client) Delete(ctx context.Context, obj runtime.Object, opts ...client.DeleteOption) error {
+	dryRun, err := c.enabledFor(ctx, obj)
+	if err != nil {
+		return err
+	}
+	if dryRun {
+		c.log("delete", obj)
+		opts = append(opts, client.DryRunAll)
+	}
+	return c.Client.Delete(ctx, obj, opts...)
+}
+
+// DeleteAllOf implements client.Client.
+// As the objects to delete aren't known upfront and DeleteAllOf has no dry-run option, nothing is deleted
+// if dry-run mode may be enabled for any of them: for all objects, for the Cluster selected with the
+// cluster name label or, if no Cluster is selected, for any Cluster in the namespace.
+func (c *Client) DeleteAllOf(ctx context.Context, obj runtime.Object, opts ...client.DeleteAllOfOption) error {
+	dryRun, err := c.enabledForAllOf(ctx, opts...)
+	if err != nil {
+		return err
+	}
+	if dryRun {
+		c.log("delete all of", obj)
+		return nil
+	}
+	return c.Client.DeleteAllOf(ctx, obj, opts...)
+}
+
+// Status implements client.StatusClient.
+func (c *Client) Status() client.StatusWriter {
+	return &statusWriter{StatusWriter: c.Client.Status(), client: c}
+}
+
+// statusWriter wraps a client.StatusWriter so that changes to the status of objects are not persisted
+// if dry-run mode is enabled for them.
+type statusWriter struct {
+	client.StatusWriter
+	client *Client
+}
+
+// Update implements client.StatusWriter.
+func (s *statusWriter) Update(ctx context.Context, obj runtime.Object, opts ...client.UpdateOption) error {
+	dryRun, err := s.client.enabledFor(ctx, obj)
+	if err != nil {
+		return err
+	}
+	if dryRun {
+		s.client.log("update status", obj)
+		opts = append(opts, client.DryRunAll)
+	}
+	return s.StatusWriter.Update(ctx, obj, opts...)
+}
+
+// Patch implements client.StatusWriter.
+func (s *statusWriter) Patch(ctx context.Context, obj runtime.Object, patch client.Patch, opts ...client.PatchOption) error {
+	dryRun, err := s.client.enabledFor(ctx, obj)
+	if err != nil {
+		return err
+	}
+	if dryRun {
+		s.client.logPatch("patch status", obj, patch)
+		opts = append(opts, client.DryRunAll)
+	}
+	return s.StatusWriter.Patch(ctx, obj, patch, opts...)
+}
+
+// enabledFor returns true if changes to obj must not be persisted.
+func (c *Client) enabledFor(ctx context.Context, obj runtime.Object) (bool, error) {
+	if c.All {
+		return true, nil
+	}
+
+	if cluster, ok := obj.(*clusterv1.Cluster); ok {
+		return annotations.HasDryRunAnnotation(cluster), nil
+	}
+
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return false, err
+	}
+	clusterName := clusterNameOf(obj)
+	if clusterName == "" {
+		return false, nil
+	}
+
+	return c.enabledForCluster(ctx, accessor.GetNamespace(), clusterName)
+}
+
+// enabledForAllOf returns true if changes to any of the objects selected by opts must not be persisted.
+func (c *Client) enabledForAllOf(ctx context.Context, opts ...client.DeleteAllOfOption) (bool, error) {
+	if c.All {
+		return true, nil
+	}
+
+	deleteOpts := &client.DeleteAllOfOptions{}
+	deleteOpts.ApplyOptions(opts)
+	if deleteOpts.LabelSelector != nil {
+		if clusterName, ok := deleteOpts.LabelSelector.RequiresExactMatch(clusterv1.ClusterLabelName); ok {
+			return c.enabledForCluster(ctx, deleteOpts.Namespace, clusterName)
+		}
+	}
+
+	clusters := &clusterv1.ClusterList{}
+	if err := c.Client.List(ctx, clusters, client.InNamespace(deleteOpts.Namespace)); err != nil {
+		return false, errors.Wrapf(err, "failed to list Clusters in namespace %q to check for dry-run mode", deleteOpts.Namespace)
+	}
+	for i := range clusters.Items {
+		if annotations.HasDryRunAnnotation(&clusters.Items[i]) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// enabledForCluster returns true if the Cluster has the dry-run annotation.
+func (c *Client) enabledForCluster(ctx context.Context, namespace, name string) (bool, error) {
+	cluster := &clusterv1.Cluster{}
+	key := client.ObjectKey{Namespace: namespace, Name: name}
+	if err := c.Client.Get(ctx, key, cluster); err != nil {
+		if apierrors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, errors.Wrapf(err, "failed to get Cluster %q in namespace %q to check for dry-run mode", key.Name, key.Namespace)
+	}
+	return annotations.HasDryRunAnnotation(cluster), nil
+}
+
+// clusterNameOf returns the name of the Cluster obj belongs to, if any.
+// Objects which aren't labeled with the cluster name, e.g. control planes, belong to the Cluster owning them.
+func clusterNameOf(obj runtime.Object) string {
+	switch o := obj.(type) {
+	case *clusterv1.Machine:
+		return o.Spec.ClusterName
+	case *clusterv1.MachineSet:
+		return o.Spec.ClusterName
+	case *clusterv1.MachineDeployment:
+		return o.Spec.ClusterName
+	case *clusterv1.MachineHealthCheck:
+		return o.Spec.ClusterName
+	}
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return ""
+	}
+	if name, ok := accessor.GetLabels()[clusterv1.ClusterLabelName]; ok {
+		return name
+	}
+	for _, ref := range accessor.GetOwnerReferences() {
+		if ref.Kind == "Cluster" && ref.APIVersion == clusterv1.GroupVersion.String() {
+			return ref.Name
+		}
+	}
+	return ""
+}
+
+func (c *Client) log(action string, obj runtime.Object) {
+	c.logger(action, obj).Info("Dry-run: not persisting change")
+}
+
+func (c *Client) logPatch(action string, obj runtime.Object, patch client.Patch) {
+	logger := c.logger(action, obj)
+	if data, err := patch.Data(obj); err == nil {
+		logger = logger.WithValues("patch", string(data))
+	}
+	logger.Info("Dry-run: not persisting change")
+}
+
+func (c *Client) logger(action string, obj runtime.Object) logr.Logger {
+	logger := c.Log.WithValues("action", action, "kind", kindOf(obj))
+	if accessor, err := meta.Accessor(obj); err == nil {
+		logger = logger.WithValues("namespace", accessor.GetNamespace(), "name", accessor.GetName())
+	}
+	return logger
+}
+
+// kindOf returns the kind of obj, falling back to its type name if the kind isn't set.
+func kindOf(obj runtime.Object) string {
+	if kind := obj.GetObjectKind().GroupVersionKind().Kind; kind != "" {
+		return kind
+	}
+	t := reflect.TypeOf(obj)
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Name()
+}
@@ -0,0 +1,245 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dryrun
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+)
+
+func newMachine(name, clusterName string) *clusterv1.Machine {
+	return &clusterv1.Machine{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "default",
+		},
+		Spec: clusterv1.MachineSpec{
+			ClusterName: clusterName,
+		},
+	}
+}
+
+func TestClientCreate(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := clusterv1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	dryRunCluster := &clusterv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "dry-run",
+			Namespace:   "default",
+			Annotations: map[string]string{clusterv1.DryRunAnnotation: ""},
+		},
+	}
+	cluster := &clusterv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test",
+			Namespace: "default",
+		},
+	}
+
+	tests := []struct {
+		name      string
+		all       bool
+		machine   *clusterv1.Machine
+		persisted bool
+	}{
+		{
+			name:      "persists machine of cluster without dry-run annotation",
+			machine:   newMachine("machine", "test"),
+			persisted: true,
+		},
+		{
+			name:      "persists machine without cluster",
+			machine:   newMachine("machine", "missing"),
+			persisted: true,
+		},
+		{
+			name:      "doesn't persist machine of cluster with dry-run annotation",
+			machine:   newMachine("machine", "dry-run"),
+			persisted: false,
+		},
+		{
+			name:      "doesn't persist any machine in dry-run mode",
+			all:       true,
+			machine:   newMachine("machine", "test"),
+			persisted: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			c := NewClient(fake.NewFakeClientWithScheme(scheme, dryRunCluster.DeepCopy(), cluster.DeepCopy()), tt.all, log.Log)
+			g.Expect(c.Create(context.TODO(), tt.machine)).To(Succeed())
+
+			err := c.Get(context.TODO(), client.ObjectKey{Namespace: "default", Name: tt.machine.Name}, &clusterv1.Machine{})
+			if tt.persisted {
+				g.Expect(err).NotTo(HaveOccurred())
+			} else {
+				g.Expect(apierrors.IsNotFound(err)).To(BeTrue())
+			}
+		})
+	}
+}
+
+func TestClientUpdateCluster(t *testing.T) {
+	g := NewWithT(t)
+
+	scheme := runtime.NewScheme()
+	g.Expect(clusterv1.AddToScheme(scheme)).To(Succeed())
+
+	cluster := &clusterv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "test",
+			Namespace:   "default",
+			Annotations: map[string]string{clusterv1.DryRunAnnotation: ""},
+		},
+	}
+	c := NewClient(fake.NewFakeClientWithScheme(scheme, cluster), false, log.Log)
+	key := client.ObjectKey{Namespace: "default", Name: "test"}
+
+	cluster = &clusterv1.Cluster{}
+	g.Expect(c.Get(context.TODO(), key, cluster)).To(Succeed())
+	cluster.Labels = map[string]string{"foo": "bar"}
+	g.Expect(c.Update(context.TODO(), cluster)).To(Succeed())
+
+	cluster = &clusterv1.Cluster{}
+	g.Expect(c.Get(context.TODO(), key, cluster)).To(Succeed())
+	g.Expect(cluster.Labels).NotTo(HaveKey("foo"))
+}
+
+func TestClientDeleteAllOf(t *testing.T) {
+	// The fake client resolves the kind of the objects to delete with the client-go scheme.
+	if err := clusterv1.AddToScheme(scheme.Scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	dryRunCluster := &clusterv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "dry-run",
+			Namespace:   "dry-run",
+			Annotations: map[string]string{clusterv1.DryRunAnnotation: ""},
+		},
+	}
+	cluster := &clusterv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test",
+			Namespace: "dry-run",
+		},
+	}
+	otherCluster := &clusterv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test",
+			Namespace: "default",
+		},
+	}
+
+	tests := []struct {
+		name    string
+		all     bool
+		opts    []client.DeleteAllOfOption
+		deleted bool
+	}{
+		{
+			name:    "deletes machines in namespace without clusters with dry-run annotation",
+			opts:    []client.DeleteAllOfOption{client.InNamespace("default")},
+			deleted: true,
+		},
+		{
+			name:    "deletes machines of cluster without dry-run annotation",
+			opts:    []client.DeleteAllOfOption{client.InNamespace("dry-run"), client.MatchingLabels{clusterv1.ClusterLabelName: "test"}},
+			deleted: true,
+		},
+		{
+			name:    "doesn't delete machines of cluster with dry-run annotation",
+			opts:    []client.DeleteAllOfOption{client.InNamespace("dry-run"), client.MatchingLabels{clusterv1.ClusterLabelName: "dry-run"}},
+			deleted: false,
+		},
+		{
+			name:    "doesn't delete machines in namespace with a cluster with dry-run annotation",
+			opts:    []client.DeleteAllOfOption{client.InNamespace("dry-run")},
+			deleted: false,
+		},
+		{
+			name:    "doesn't delete any machine in dry-run mode",
+			all:     true,
+			opts:    []client.DeleteAllOfOption{client.InNamespace("default")},
+			deleted: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			machine := newMachine("machine", "test")
+			machine.Labels = map[string]string{clusterv1.ClusterLabelName: "test"}
+			dryRunMachine := newMachine("machine", "dry-run")
+			dryRunMachine.Namespace = "dry-run"
+			dryRunMachine.Labels = map[string]string{clusterv1.ClusterLabelName: "dry-run"}
+			testMachine := newMachine("test-machine", "test")
+			testMachine.Namespace = "dry-run"
+			testMachine.Labels = map[string]string{clusterv1.ClusterLabelName: "test"}
+
+			c := NewClient(fake.NewFakeClientWithScheme(scheme.Scheme,
+				dryRunCluster.DeepCopy(), cluster.DeepCopy(), otherCluster.DeepCopy(), machine, dryRunMachine, testMachine), tt.all, log.Log)
+			g.Expect(c.DeleteAllOf(context.TODO(), &clusterv1.Machine{}, tt.opts...)).To(Succeed())
+
+			machines := &clusterv1.MachineList{}
+			g.Expect(c.List(context.TODO(), machines)).To(Succeed())
+			if tt.deleted {
+				g.Expect(machines.Items).To(HaveLen(2))
+			} else {
+				g.Expect(machines.Items).To(HaveLen(3))
+			}
+		})
+	}
+}
+
+func TestClusterNameOf(t *testing.T) {
+	g := NewWithT(t)
+
+	g.Expect(clusterNameOf(newMachine("machine", "test"))).To(Equal("test"))
+
+	labeled := &clusterv1.Cluster{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{clusterv1.ClusterLabelName: "test"}}}
+	g.Expect(clusterNameOf(labeled)).To(Equal("test"))
+
+	owned := &unstructured.Unstructured{}
+	owned.SetOwnerReferences([]metav1.OwnerReference{
+		{APIVersion: "other.io/v1", Kind: "Cluster", Name: "other"},
+		{APIVersion: clusterv1.GroupVersion.String(), Kind: "Cluster", Name: "test"},
+	})
+	g.Expect(clusterNameOf(owned)).To(Equal("test"))
+
+	g.Expect(clusterNameOf(&unstructured.Unstructured{})).To(BeEmpty())
+}
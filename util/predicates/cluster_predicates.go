@@ -149,6 +149,43 @@ func ClusterUpdateUnpaused(logger logr.Logger) predicate.Funcs {
 	}
 }
 
+// ClusterControlPlaneEndpointChanged returns a predicate that returns true for an update event when a cluster has
+// Spec.ControlPlaneEndpoint changed
+// it returns false if the resource provided is not a Cluster
+func ClusterControlPlaneEndpointChanged(logger logr.Logger) predicate.Funcs {
+	log := logger.WithValues("predicate", "ClusterControlPlaneEndpointChanged")
+	return predicate.Funcs{
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			log = log.WithValues("eventType", "update")
+
+			oldCluster, ok := e.ObjectOld.(*clusterv1.Cluster)
+			if !ok {
+
+				log.V(4).Info("Expected Cluster", "type", e.ObjectOld.GetObjectKind().GroupVersionKind().String())
+				return false
+			}
+			log = log.WithValues("namespace", oldCluster.Namespace, "cluster", oldCluster.Name)
+
+			newCluster, ok := e.ObjectNew.(*clusterv1.Cluster)
+			if !ok {
+				log.V(4).Info("Expected Cluster", "type", e.ObjectNew.GetObjectKind().GroupVersionKind().String())
+				return false
+			}
+
+			if oldCluster.Spec.ControlPlaneEndpoint != newCluster.Spec.ControlPlaneEndpoint {
+				log.V(4).Info("Cluster control plane endpoint changed, allowing further processing")
+				return true
+			}
+
+			log.V(4).Info("Cluster control plane endpoint did not change, blocking further processing")
+			return false
+		},
+		CreateFunc:  func(e event.CreateEvent) bool { return false },
+		DeleteFunc:  func(e event.DeleteEvent) bool { return false },
+		GenericFunc: func(e event.GenericEvent) bool { return false },
+	}
+}
+
 // ClusterUnpaused returns a Predicate that returns true on Cluster creation events where Cluster.Spec.Paused is false
 // and Update events when Cluster.Spec.Paused transitions to false.
 // This implements a common requirement for many cluster-api and provider controllers (such as Cluster Infrastructure
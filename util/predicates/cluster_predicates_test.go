@@ -0,0 +1,103 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package predicates
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func TestClusterControlPlaneEndpointChanged(t *testing.T) {
+	newCluster := func(host string, port int32) *clusterv1.Cluster {
+		return &clusterv1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "test-cluster"},
+			Spec: clusterv1.ClusterSpec{
+				ControlPlaneEndpoint: clusterv1.APIEndpoint{Host: host, Port: port},
+			},
+		}
+	}
+	secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "test-cluster-kubeconfig"}}
+
+	tests := []struct {
+		name   string
+		oldObj runtime.Object
+		newObj runtime.Object
+		want   bool
+	}{
+		{
+			name:   "endpoint set",
+			oldObj: newCluster("", 0),
+			newObj: newCluster("example.com", 6443),
+			want:   true,
+		},
+		{
+			name:   "host changed",
+			oldObj: newCluster("example.com", 6443),
+			newObj: newCluster("example.org", 6443),
+			want:   true,
+		},
+		{
+			name:   "port changed",
+			oldObj: newCluster("example.com", 6443),
+			newObj: newCluster("example.com", 443),
+			want:   true,
+		},
+		{
+			name:   "endpoint unchanged",
+			oldObj: newCluster("example.com", 6443),
+			newObj: newCluster("example.com", 6443),
+			want:   false,
+		},
+		{
+			name:   "not a cluster",
+			oldObj: secret,
+			newObj: secret,
+			want:   false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			p := ClusterControlPlaneEndpointChanged(log.Log)
+			e := event.UpdateEvent{
+				MetaOld:   tt.oldObj.(metav1.Object),
+				ObjectOld: tt.oldObj,
+				MetaNew:   tt.newObj.(metav1.Object),
+				ObjectNew: tt.newObj,
+			}
+			g.Expect(p.Update(e)).To(Equal(tt.want))
+		})
+	}
+
+	t.Run("ignores create, delete and generic events", func(t *testing.T) {
+		g := NewWithT(t)
+
+		p := ClusterControlPlaneEndpointChanged(log.Log)
+		c := newCluster("example.com", 6443)
+		g.Expect(p.Create(event.CreateEvent{Meta: c, Object: c})).To(BeFalse())
+		g.Expect(p.Delete(event.DeleteEvent{Meta: c, Object: c})).To(BeFalse())
+		g.Expect(p.Generic(event.GenericEvent{Meta: c, Object: c})).To(BeFalse())
+	})
+}
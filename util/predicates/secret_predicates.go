@@ -0,0 +1,90 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package predicates
+
+import (
+	"bytes"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/cluster-api/util/secret"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+// KubeconfigSecretChanged returns a predicate that returns true for a create event of a Cluster's kubeconfig Secret
+// and for an update event when the kubeconfig stored in the Secret changed
+// it returns false if the resource provided is not a kubeconfig Secret
+func KubeconfigSecretChanged(logger logr.Logger) predicate.Funcs {
+	log := logger.WithValues("predicate", "KubeconfigSecretChanged")
+	return predicate.Funcs{
+		CreateFunc: func(e event.CreateEvent) bool {
+			log = log.WithValues("eventType", "create")
+
+			s, ok := e.Object.(*corev1.Secret)
+			if !ok {
+				log.V(4).Info("Expected Secret", "type", e.Object.GetObjectKind().GroupVersionKind().String())
+				return false
+			}
+			log = log.WithValues("namespace", s.Namespace, "secret", s.Name)
+
+			if !isKubeconfigSecret(s) {
+				log.V(4).Info("Secret is not a kubeconfig Secret, blocking further processing")
+				return false
+			}
+
+			log.V(4).Info("Kubeconfig Secret was created, allowing further processing")
+			return true
+		},
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			log = log.WithValues("eventType", "update")
+
+			oldSecret, ok := e.ObjectOld.(*corev1.Secret)
+			if !ok {
+				log.V(4).Info("Expected Secret", "type", e.ObjectOld.GetObjectKind().GroupVersionKind().String())
+				return false
+			}
+			log = log.WithValues("namespace", oldSecret.Namespace, "secret", oldSecret.Name)
+
+			newSecret, ok := e.ObjectNew.(*corev1.Secret)
+			if !ok {
+				log.V(4).Info("Expected Secret", "type", e.ObjectNew.GetObjectKind().GroupVersionKind().String())
+				return false
+			}
+
+			if !isKubeconfigSecret(newSecret) {
+				log.V(4).Info("Secret is not a kubeconfig Secret, blocking further processing")
+				return false
+			}
+
+			if !bytes.Equal(oldSecret.Data[secret.KubeconfigDataName], newSecret.Data[secret.KubeconfigDataName]) {
+				log.V(4).Info("Kubeconfig changed, allowing further processing")
+				return true
+			}
+
+			log.V(4).Info("Kubeconfig did not change, blocking further processing")
+			return false
+		},
+		DeleteFunc:  func(e event.DeleteEvent) bool { return false },
+		GenericFunc: func(e event.GenericEvent) bool { return false },
+	}
+}
+
+func isKubeconfigSecret(s *corev1.Secret) bool {
+	_, purpose, err := secret.ParseSecretName(s.Name)
+	return err == nil && purpose == secret.Kubeconfig
+}
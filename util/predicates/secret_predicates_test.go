@@ -0,0 +1,97 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package predicates
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/cluster-api/util/secret"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func TestKubeconfigSecretChanged(t *testing.T) {
+	newSecret := func(name, kubeconfig string, labels map[string]string) *corev1.Secret {
+		return &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name, Labels: labels},
+			Data:       map[string][]byte{secret.KubeconfigDataName: []byte(kubeconfig)},
+		}
+	}
+
+	t.Run("create", func(t *testing.T) {
+		g := NewWithT(t)
+
+		p := KubeconfigSecretChanged(log.Log)
+		kubeconfig := newSecret("test-cluster-kubeconfig", "a", nil)
+		g.Expect(p.Create(event.CreateEvent{Meta: kubeconfig, Object: kubeconfig})).To(BeTrue())
+		ca := newSecret("test-cluster-ca", "a", nil)
+		g.Expect(p.Create(event.CreateEvent{Meta: ca, Object: ca})).To(BeFalse())
+	})
+
+	tests := []struct {
+		name   string
+		oldObj *corev1.Secret
+		newObj *corev1.Secret
+		want   bool
+	}{
+		{
+			name:   "kubeconfig changed",
+			oldObj: newSecret("test-cluster-kubeconfig", "a", nil),
+			newObj: newSecret("test-cluster-kubeconfig", "b", nil),
+			want:   true,
+		},
+		{
+			name:   "only metadata changed",
+			oldObj: newSecret("test-cluster-kubeconfig", "a", nil),
+			newObj: newSecret("test-cluster-kubeconfig", "a", map[string]string{"foo": "bar"}),
+			want:   false,
+		},
+		{
+			name:   "resync",
+			oldObj: newSecret("test-cluster-kubeconfig", "a", nil),
+			newObj: newSecret("test-cluster-kubeconfig", "a", nil),
+			want:   false,
+		},
+		{
+			name:   "not a kubeconfig secret",
+			oldObj: newSecret("test-cluster-ca", "a", nil),
+			newObj: newSecret("test-cluster-ca", "b", nil),
+			want:   false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			p := KubeconfigSecretChanged(log.Log)
+			e := event.UpdateEvent{MetaOld: tt.oldObj, ObjectOld: tt.oldObj, MetaNew: tt.newObj, ObjectNew: tt.newObj}
+			g.Expect(p.Update(e)).To(Equal(tt.want))
+		})
+	}
+
+	t.Run("delete and generic", func(t *testing.T) {
+		g := NewWithT(t)
+
+		p := KubeconfigSecretChanged(log.Log)
+		s := newSecret("test-cluster-kubeconfig", "a", nil)
+		g.Expect(p.Delete(event.DeleteEvent{Meta: s, Object: s})).To(BeFalse())
+		g.Expect(p.Generic(event.GenericEvent{Meta: s, Object: s})).To(BeFalse())
+	})
+}